@@ -0,0 +1,134 @@
+package goparse
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CommandKey is the reserved key under which the selected subcommand's name
+// is stored in the parsed values.
+const CommandKey = "_command"
+
+// AddCommand registers a subcommand and returns its parser, on which the
+// subcommand's own arguments are added. When the first non-flag token matches
+// the command name, everything after it is parsed by the returned parser and
+// its values are merged with the global ones.
+func (p *Parser) AddCommand(name, description string) *Parser {
+	cmd := NewParser(WithName(name), WithDescription(description))
+	cmd.parent = p
+	cmd.output = p.output
+	cmd.errOutput = p.errOutput
+	cmd.helpStyle = p.helpStyle
+	cmd.translator = p.translator
+	cmd.quiet = p.quiet
+	cmd.withoutDefaults = p.withoutDefaults
+	cmd.parseDebug = p.parseDebug
+	p.commands = append(p.commands, cmd)
+	return cmd
+}
+
+// OnlyFor restricts a global argument to the named subcommands, rejecting it
+// when any other (or no) subcommand is selected.
+func (a *Argument) OnlyFor(commands ...string) *Argument {
+	a.onlyFor = commands
+	return a
+}
+
+func (p *Parser) lookupCommand(name string) *Parser {
+	for _, cmd := range p.commands {
+		if cmd.Name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// splitCommand separates the global arguments from a subcommand and its
+// arguments. Values of global flags are skipped so that a value which happens
+// to match a command name does not select it.
+func (p *Parser) splitCommand(args []string) ([]string, *Parser, []string) {
+	if len(p.commands) == 0 {
+		return args, nil, nil
+	}
+
+	index := p.buildFlagIndex(p.args)
+	for i := 0; i < len(args); i++ {
+		// Nothing after a "--" terminator can select a command
+		if args[i] == "--" {
+			return args, nil, nil
+		}
+		if !strings.HasPrefix(args[i], "-") {
+			if cmd := p.lookupCommand(args[i]); cmd != nil {
+				return args[:i], cmd, args[i+1:]
+			}
+			continue
+		}
+
+		// A value attached with "=" is part of the flag token
+		if strings.Contains(args[i], "=") {
+			continue
+		}
+		if def := p.lookupFlag(index, args[i]); def != nil {
+			if takesNextToken(def) {
+				i++
+			}
+			continue
+		}
+
+		// In a short cluster the first valued flag takes the rest of the
+		// cluster as its value, or the next token when it comes last (-vo out)
+		if !strings.HasPrefix(args[i], "--") {
+			for j := 1; j < len(args[i]); j++ {
+				def := index["-"+string(args[i][j])]
+				if def == nil || def.DataType == "bool" || def.DataType == "count" {
+					continue
+				}
+				if j == len(args[i])-1 && takesNextToken(def) {
+					i++
+				}
+				break
+			}
+		}
+	}
+	return args, nil, nil
+}
+
+// takesNextToken reports whether a flag given without an attached value
+// consumes the following token as its value
+func takesNextToken(def *Argument) bool {
+	return def.DataType != "bool" && def.DataType != "count" && !def.optionalValue
+}
+
+// validateOnlyFor checks that command-scoped global arguments were only used
+// with their commands.
+func (p *Parser) validateOnlyFor(cmd *Parser) error {
+	for _, arg := range p.args {
+		if len(arg.onlyFor) == 0 || !p.provided[arg.Name] {
+			continue
+		}
+
+		allowed := false
+		for _, name := range arg.onlyFor {
+			if cmd != nil && cmd.Name == name {
+				allowed = true
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("%s is only valid with the '%s' command", p.displayName(arg.Name), strings.Join(arg.onlyFor, "' or '"))
+		}
+	}
+	return nil
+}
+
+func (p *Parser) unknownCommandError(name string) error {
+	if p.commandSuggestions {
+		names := []string{}
+		for _, cmd := range p.commands {
+			names = append(names, cmd.Name)
+		}
+		if suggestion := suggest(name, names); suggestion != "" {
+			return fmt.Errorf("unknown command '%s'; did you mean '%s'?", name, suggestion)
+		}
+	}
+	return fmt.Errorf("unknown command '%s'", name)
+}
@@ -0,0 +1,115 @@
+package goparse
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// CompleteKey is the hidden first argument with which a generated completion
+// script calls back into the program for candidates.
+const CompleteKey = "__complete"
+
+// CompleteWith supplies the values offered when completing the argument's
+// value in the shell, e.g. a function listing the available profiles. Without
+// it, an argument with Choices completes to its choices.
+func (a *Argument) CompleteWith(values func() []string) *Argument {
+	a.completeWith = values
+	return a
+}
+
+// Complete returns the completion candidates for a partial command line. words
+// are the arguments typed so far, excluding the program name; the last one is
+// the word being completed and may be empty.
+func (p *Parser) Complete(words []string) []string {
+	current, previous := "", ""
+	if len(words) > 0 {
+		current = words[len(words)-1]
+	}
+	if len(words) > 1 {
+		previous = words[len(words)-2]
+	}
+
+	// Complete a value when the previous word is a flag that takes one
+	index := p.buildFlagIndex(p.args)
+	if def := p.lookupFlag(index, previous); def != nil && def.DataType != "bool" && def.DataType != "count" {
+		return filterPrefix(def.completionValues(), current)
+	}
+
+	candidates := []string{}
+	for _, arg := range p.args {
+		if arg.replaced {
+			continue
+		}
+		if arg.Long != "" {
+			candidates = append(candidates, "--"+arg.Long)
+		} else if arg.Short != "" {
+			candidates = append(candidates, "-"+arg.Short)
+		}
+	}
+	if !strings.HasPrefix(current, "-") {
+		for _, cmd := range p.commands {
+			candidates = append(candidates, cmd.Name)
+		}
+	}
+	sort.Strings(candidates)
+	return filterPrefix(candidates, current)
+}
+
+// completionValues returns the values offered for the argument's value
+func (a *Argument) completionValues() []string {
+	if a.completeWith != nil {
+		return a.completeWith()
+	}
+	return a.choices
+}
+
+// WriteBashCompletion writes a bash completion script for the program. Static
+// Choices are inlined; everything else calls back into the program with the
+// hidden __complete argument, which Parse answers without running anything.
+func (p *Parser) WriteBashCompletion(w io.Writer) error {
+	program := p.InvocationName()
+	function := "_" + strings.NewReplacer("-", "_", ".", "_").Replace(program) + "_complete"
+
+	var script strings.Builder
+	fmt.Fprintf(&script, "%s() {\n", function)
+	script.WriteString("    local cur=\"${COMP_WORDS[COMP_CWORD]}\" prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	script.WriteString("    case \"$prev\" in\n")
+	for _, arg := range p.args {
+		if len(arg.choices) == 0 || arg.completeWith != nil {
+			continue
+		}
+		forms := []string{}
+		if arg.Short != "" {
+			forms = append(forms, "-"+arg.Short)
+		}
+		if arg.Long != "" {
+			forms = append(forms, "--"+arg.Long)
+		}
+		if len(forms) == 0 {
+			continue
+		}
+		fmt.Fprintf(&script, "        %s)\n", strings.Join(forms, "|"))
+		fmt.Fprintf(&script, "            COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(arg.choices, " "))
+		script.WriteString("            return ;;\n")
+	}
+	script.WriteString("    esac\n")
+	fmt.Fprintf(&script, "    COMPREPLY=($(\"${COMP_WORDS[0]}\" %s \"${COMP_WORDS[@]:1:COMP_CWORD}\" 2>/dev/null))\n", CompleteKey)
+	script.WriteString("}\n")
+	fmt.Fprintf(&script, "complete -F %s %s\n", function, program)
+
+	_, err := io.WriteString(w, script.String())
+	return err
+}
+
+// filterPrefix returns the candidates starting with prefix
+func filterPrefix(candidates []string, prefix string) []string {
+	matches := []string{}
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, prefix) {
+			matches = append(matches, candidate)
+		}
+	}
+	return matches
+}
@@ -0,0 +1,328 @@
+package goparse
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AutoConfig designates the string argument that holds a config file path.
+// When it is set on the command line or through the environment, the JSON or
+// YAML file is loaded and its values, keyed by argument name, are used for
+// every argument not set either way. Precedence is command line, then
+// environment, then config file, then built-in defaults.
+func (p *Parser) AutoConfig(flagName string) {
+	p.autoConfig = flagName
+}
+
+func (p *Parser) applyAutoConfig(parsedArgs map[string]interface{}) error {
+	// Defaults are not applied yet, so a value here was set explicitly
+	path, ok := parsedArgs[p.autoConfig].(string)
+	if !ok {
+		return nil
+	}
+
+	values, err := loadConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	for name, value := range values {
+		arg := p.lookupArgument(name)
+		if arg == nil {
+			return fmt.Errorf("unknown argument in config file %s: %s", path, name)
+		}
+		// An empty "key:" or null leaves the argument unset
+		if _, ok := parsedArgs[name]; ok || value == nil {
+			continue
+		}
+
+		converted, err := convertConfigValue(arg, value)
+		if err != nil {
+			return fmt.Errorf("config file %s: %v", path, err)
+		}
+		parsedArgs[name] = converted
+		p.external[name] = true
+	}
+	return nil
+}
+
+// WriteDefaultConfig writes a config file template for AutoConfig with every
+// argument and its default, in "json" or "yaml" format. The YAML template
+// carries descriptions as comments and lists arguments without a default
+// commented out; JSON has no comments, so it only includes arguments with a
+// default.
+func (p *Parser) WriteDefaultConfig(w io.Writer, format string) error {
+	args := []*Argument{}
+	for _, arg := range p.args {
+		// The config path itself and renamed flags don't belong in the file
+		if arg.Name != p.autoConfig && !arg.replaced {
+			args = append(args, arg)
+		}
+	}
+	sort.Slice(args, func(i, j int) bool {
+		return args[i].Name < args[j].Name
+	})
+
+	switch format {
+	case "json":
+		values := map[string]interface{}{}
+		for _, arg := range args {
+			if arg.DefaultValue != nil {
+				values[arg.Name] = jsonDefault(arg.DefaultValue)
+			}
+		}
+		out, err := json.MarshalIndent(values, "", "  ")
+		if err != nil {
+			return fmt.Errorf("could not write config: %v", err)
+		}
+		_, err = fmt.Fprintln(w, string(out))
+		return err
+	case "yaml":
+		var out strings.Builder
+		for i, arg := range args {
+			if i > 0 {
+				out.WriteString("\n")
+			}
+			if arg.Description != "" {
+				fmt.Fprintf(&out, "# %s\n", arg.Description)
+			}
+			writeYAMLDefault(&out, arg)
+		}
+		_, err := io.WriteString(w, out.String())
+		return err
+	}
+	return fmt.Errorf("unknown config format '%s': expected json or yaml", format)
+}
+
+// jsonDefault returns a default value as it is written to JSON. Durations
+// are written as strings such as "30s", which read back as durations; JSON
+// would otherwise encode them as a count of nanoseconds.
+func jsonDefault(value interface{}) interface{} {
+	if duration, ok := value.(time.Duration); ok {
+		return duration.String()
+	}
+	return value
+}
+
+// writeYAMLDefault writes one "name: default" entry in the YAML that
+// parseYAMLDocument reads back
+func writeYAMLDefault(out *strings.Builder, arg *Argument) {
+	items := []string{}
+	switch value := arg.DefaultValue.(type) {
+	case nil:
+		fmt.Fprintf(out, "# %s:\n", arg.Name)
+		return
+	case map[string]string:
+		keys := []string{}
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		fmt.Fprintf(out, "%s:\n", arg.Name)
+		for _, key := range keys {
+			fmt.Fprintf(out, "  %s: %s\n", quoteYAML(key), quoteYAML(value[key]))
+		}
+		return
+	case []string:
+		for _, item := range value {
+			items = append(items, quoteYAML(item))
+		}
+	case []int:
+		for _, item := range value {
+			items = append(items, fmt.Sprint(item))
+		}
+	case string:
+		fmt.Fprintf(out, "%s: %s\n", arg.Name, quoteYAML(value))
+		return
+	default:
+		// Numbers, bools and durations read back as the same value unquoted
+		fmt.Fprintf(out, "%s: %v\n", arg.Name, value)
+		return
+	}
+
+	fmt.Fprintf(out, "%s:\n", arg.Name)
+	for _, item := range items {
+		fmt.Fprintf(out, "  - %s\n", item)
+	}
+}
+
+// quoteYAML single-quotes strings that would otherwise be misread, the inverse
+// of unquoteYAML: anything parseYAMLScalar would retype, such as 007, true,
+// ~ or [a], and anything that looks like YAML structure.
+func quoteYAML(value string) string {
+	if value == "" || value != strings.TrimSpace(value) || strings.ContainsAny(value, ":#'\"") || strings.HasPrefix(value, "- ") {
+		return "'" + value + "'"
+	}
+	if text, ok := parseYAMLScalar(value).(string); !ok || text != value {
+		return "'" + value + "'"
+	}
+	return value
+}
+
+// envName returns the environment variable read for the argument
+func (p *Parser) envName(arg *Argument) string {
+	name := strings.NewReplacer("-", "_", ".", "_").Replace(arg.Name)
+	return strings.ToUpper(p.envPrefix + "_" + name)
+}
+
+func (p *Parser) applyEnv(parsedArgs map[string]interface{}) error {
+	for _, arg := range p.args {
+		rawValue, ok := os.LookupEnv(p.envName(arg))
+		if ok && arg.source == "cli" {
+			return fmt.Errorf("%s cannot be set via %s, only on the command line", p.displayName(arg.Name), p.envName(arg))
+		}
+		if _, set := parsedArgs[arg.key()]; set || !ok {
+			continue
+		}
+
+		var value interface{}
+		var err error
+		if strings.HasPrefix(arg.DataType, "[]") {
+			value, err = convertSlice(arg, strings.Split(rawValue, ","))
+		} else if arg.DataType == "map[string]string" {
+			value, err = convertMap(arg, strings.Split(rawValue, ","))
+		} else {
+			value, err = convertValue(arg, p.envName(arg), rawValue)
+		}
+		if err != nil {
+			return fmt.Errorf("environment variable %s: %v", p.envName(arg), err)
+		}
+		parsedArgs[arg.key()] = value
+		p.raw[arg.key()] = []string{rawValue}
+		p.external[arg.key()] = true
+	}
+	return nil
+}
+
+// applyStdin sets the first unset FromStdinIfAbsent argument from standard
+// input. A terminal is never read, so an interactive run cannot block here and
+// falls through to the default or the required check instead.
+func (p *Parser) applyStdin(parsedArgs map[string]interface{}) error {
+	for _, arg := range p.args {
+		if !arg.fromStdin {
+			continue
+		}
+		if _, ok := parsedArgs[arg.key()]; ok || isCharDevice(os.Stdin) {
+			continue
+		}
+
+		contents, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("could not read value for argument '%s' from stdin: %v", arg.Name, err)
+		}
+		rawValue := strings.TrimSuffix(strings.TrimSuffix(string(contents), "\n"), "\r")
+		if rawValue == "" {
+			return nil
+		}
+
+		value, err := convertValue(arg, "stdin", rawValue)
+		if err != nil {
+			return err
+		}
+		parsedArgs[arg.key()] = value
+		p.raw[arg.key()] = []string{rawValue}
+		// Stdin can only be read once
+		return nil
+	}
+	return nil
+}
+
+// convertMap converts key=value tokens into a map
+func convertMap(arg *Argument, rawValues []string) (map[string]string, error) {
+	pairs, err := convertPairs(arg, rawValues)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]string{}
+	for _, pair := range pairs {
+		values[pair.Key] = pair.Value
+	}
+	return values, nil
+}
+
+// convertConfigValue converts a decoded config value to the argument's data
+// type by running it through the same conversion as command line values.
+func convertConfigValue(arg *Argument, value interface{}) (interface{}, error) {
+	if arg.DataType == "map[string]string" {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid value for argument '%s': expected an object", arg.Name)
+		}
+		values := map[string]string{}
+		for key, item := range object {
+			values[key] = configText(item)
+		}
+		return values, nil
+	}
+	if strings.HasPrefix(arg.DataType, "[]") {
+		items, ok := value.([]interface{})
+		if !ok {
+			items = []interface{}{value}
+		}
+		values := []string{}
+		for _, item := range items {
+			values = append(values, configText(item))
+		}
+		return convertSlice(arg, values)
+	}
+	return convertValue(arg, arg.Name, configText(value))
+}
+
+// configText renders a decoded config value as the text convertValue reads.
+// Floats are written out in full, since fmt would turn 1000000 into 1e+06.
+func configText(value interface{}) string {
+	if floatValue, ok := value.(float64); ok {
+		return strconv.FormatFloat(floatValue, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+// loadConfigFile reads a JSON or YAML file into a map keyed by argument
+// name. Files ending in .json are decoded as JSON, anything else as YAML.
+func loadConfigFile(path string) (map[string]interface{}, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read config file: %v", err)
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		// Numbers keep their text, so large integers are not rounded to floats
+		values := map[string]interface{}{}
+		decoder := json.NewDecoder(bytes.NewReader(contents))
+		decoder.UseNumber()
+		if err := decoder.Decode(&values); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %v", path, err)
+		}
+		if decoder.More() {
+			return nil, fmt.Errorf("invalid config file %s: unexpected data after the top-level object", path)
+		}
+		return values, nil
+	}
+
+	// YAML shares the spec file parser
+	document, err := parseYAMLDocument(contents)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+	values, ok := document.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid config file %s: expected 'key: value' entries", path)
+	}
+	return values, nil
+}
+
+func unquoteYAML(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
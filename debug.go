@@ -0,0 +1,115 @@
+package goparse
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// DebugEnv is the environment variable that turns on the parse trace on
+// stderr, so users can troubleshoot a shipped binary without a rebuild.
+const DebugEnv = "GOPARSE_DEBUG"
+
+// WithParseDebug writes a trace of each Parse to w: the arguments, the
+// selected command and where every value came from (command line,
+// environment, config file, defaults, ...). Setting GOPARSE_DEBUG enables the
+// trace on stderr when no writer is configured.
+func WithParseDebug(w io.Writer) Option {
+	return func(p *Parser) {
+		p.parseDebug = w
+	}
+}
+
+// debugOutput returns where the parse trace goes, or nil when it is off
+func (p *Parser) debugOutput() io.Writer {
+	if p.parseDebug != nil {
+		return p.parseDebug
+	}
+	if os.Getenv(DebugEnv) != "" {
+		return os.Stderr
+	}
+	return nil
+}
+
+// trace writes one line of the parse trace
+func (p *Parser) trace(format string, a ...interface{}) {
+	if w := p.debugOutput(); w != nil {
+		fmt.Fprintf(w, "goparse: "+format+"\n", a...)
+	}
+}
+
+// traceValues traces the values set by source since the last call, marking
+// them in traced. Secret values are redacted.
+func (p *Parser) traceValues(source string, parsedArgs map[string]interface{}, traced map[string]bool) {
+	if p.debugOutput() == nil {
+		return
+	}
+
+	names := []string{}
+	for name := range parsedArgs {
+		if !traced[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		traced[name] = true
+		var value interface{} = parsedArgs[name]
+		if arg := p.lookupArgument(name); arg != nil && arg.secret {
+			value = redacted
+		}
+		p.trace("%s: %s = %v", source, name, value)
+	}
+}
+
+// redactArgs returns a copy of args for tracing with the values of Secret
+// arguments replaced, whether given as --token value, --token=value, -t value
+// or attached as -tvalue.
+func (p *Parser) redactArgs(args []string) []string {
+	index := p.buildFlagIndex(p.args)
+	takesValue := func(def *Argument) bool {
+		return def != nil && def.DataType != "bool" && def.DataType != "count"
+	}
+
+	redactedArgs := append([]string{}, args...)
+	for i := 0; i < len(redactedArgs); i++ {
+		arg := redactedArgs[i]
+		if arg == "--" {
+			break
+		}
+
+		switch {
+		case strings.HasPrefix(arg, "--"):
+			name, _, hasInline := strings.Cut(arg, "=")
+			def := p.lookupFlag(index, name)
+			if !takesValue(def) || !def.secret {
+				continue
+			}
+			if hasInline {
+				redactedArgs[i] = name + "=" + redacted
+			} else if i+1 < len(redactedArgs) {
+				redactedArgs[i+1] = redacted
+				i++
+			}
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			// The first valued flag in a cluster takes the rest or the next token
+			for j := 1; j < len(arg); j++ {
+				def := index["-"+string(arg[j])]
+				if !takesValue(def) {
+					continue
+				}
+				if def.secret && j+1 < len(arg) {
+					redactedArgs[i] = arg[:j+1] + redacted
+				} else if def.secret && i+1 < len(redactedArgs) {
+					redactedArgs[i+1] = redacted
+					i++
+				}
+				break
+			}
+		}
+	}
+	return redactedArgs
+}
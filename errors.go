@@ -0,0 +1,45 @@
+package goparse
+
+import (
+	"strings"
+)
+
+// ParseErrors is returned in the "aggregate" error mode when parsing found
+// more than one problem. Errors gives access to each of them.
+type ParseErrors struct {
+	errs	[]error
+}
+
+func (e *ParseErrors) Error() string {
+	messages := []string{}
+	for _, err := range e.errs {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Errors returns the individual problems in the order they were found
+func (e *ParseErrors) Errors() []error {
+	return e.errs
+}
+
+// Unwrap lets errors.Is and errors.As look at each problem
+func (e *ParseErrors) Unwrap() []error {
+	return e.errs
+}
+
+// fail records err and reports whether parsing should stop, which it always
+// should in the default "fast" error mode.
+func (p *Parser) fail(err error) bool {
+	p.errs = append(p.errs, err)
+	return p.errorMode != "aggregate"
+}
+
+// failure returns the error for everything recorded by fail: the error itself
+// when there is one, or a ParseErrors combining them.
+func (p *Parser) failure() error {
+	if len(p.errs) == 1 {
+		return p.errs[0]
+	}
+	return &ParseErrors{errs: p.errs}
+}
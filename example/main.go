@@ -86,7 +86,7 @@ func main() {
     parsedArgs, _, err := parser.Parse()
     if err != nil {
         fmt.Println("Error:", err)
-        parser.PrintHelp()
+        parser.PrintErrorHelp()
         return
     }
 
@@ -2,10 +2,20 @@ package goparse
 
 import (
 	"fmt"
+	"io"
+	"net"
+	"net/url"
 	"os"
+	"reflect"
+	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"text/template"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 )
 
@@ -15,14 +25,242 @@ type Argument struct {
 	Short			string
 	Long			string
 	Description		string
-	DataType 		string 		// e.g., string, []string, int, bool, etc.
+	DataType 		string 		// e.g., string, []string, int, []int, float64, float32, duration, map[string]string, bool, count, url, ip, cidr, etc.
 	DefaultValue 	interface{}
 	Required		bool
+	fileValue		bool
+	nargs			int
+	example			string
+	bareValue		interface{}
+	optionalValue	bool
+	group			string	// Title of the ArgumentGroup the argument belongs to, if any
+	linkTo			string
+	replaced		bool	// Set by ReplacedBy; linkTo names the replacement
+	onlyFor			[]string
+	onSet			func(value interface{}) error
+	implies			map[string]interface{}
+	metavar			string
+	choices			[]string
+	source			string	// "env" or "cli" when restricted to one source
+	fromStdin		bool
+	completeWith	func() []string
+	defaultFunc		func() (interface{}, error)
+	pattern			*regexp.Regexp
+	patternErr		error
+	secret			bool
+	requiredUnless	string
+	requiredIf		string
+	defaultErr		error
+}
+
+// SourceEnvOnly only accepts the argument's value from the environment (see
+// WithEnvPrefix), e.g. for secrets that must not show up in ps output. Passing
+// it on the command line is an error.
+func (a *Argument) SourceEnvOnly() *Argument {
+	a.source = "env"
+	return a
+}
+
+// SourceCLIOnly only accepts the argument's value from the command line. Setting
+// its environment variable is an error.
+func (a *Argument) SourceCLIOnly() *Argument {
+	a.source = "cli"
+	return a
+}
+
+// FromStdinIfAbsent reads the argument's value from standard input when it is
+// not given any other way and stdin is piped rather than a terminal, so
+// `echo hi | myprog` sets --body to "hi". A trailing newline is dropped.
+func (a *Argument) FromStdinIfAbsent() *Argument {
+	a.fromStdin = true
+	return a
+}
+
+// DefaultFunc computes the argument's default at parse time, and only when the
+// argument is absent, e.g. the current working directory or a fresh temp path.
+// An error from the function fails the parse. It takes precedence over a
+// static DefaultValue.
+func (a *Argument) DefaultFunc(fn func() (interface{}, error)) *Argument {
+	a.defaultFunc = fn
+	return a
+}
+
+// RequiredUnless makes the argument required unless the named argument is set,
+// e.g. --output unless --dry-run.
+func (a *Argument) RequiredUnless(name string) *Argument {
+	a.requiredUnless = name
+	return a
+}
+
+// RequiredIf makes the argument required when the named argument is set, e.g.
+// --password if --user.
+func (a *Argument) RequiredIf(name string) *Argument {
+	a.requiredIf = name
+	return a
+}
+
+// Pattern requires string values to match the regular expression, e.g.
+// "^[a-z0-9-]+$". Each element of a slice argument is checked on its own. An
+// invalid expression is reported by Validate.
+func (a *Argument) Pattern(expr string) *Argument {
+	a.pattern, a.patternErr = regexp.Compile(expr)
+	return a
+}
+
+// checkPattern reports an error when a string value does not match the
+// argument's pattern. flag names the argument in the message.
+func (a *Argument) checkPattern(flag string, value interface{}) error {
+	if a.pattern == nil {
+		return nil
+	}
+
+	items := []string{}
+	switch v := value.(type) {
+	case string:
+		items = append(items, v)
+	case []string:
+		items = v
+	}
+
+	for _, item := range items {
+		if !a.pattern.MatchString(item) {
+			return fmt.Errorf("value '%s' for %s does not match pattern %s", item, flag, a.pattern)
+		}
+	}
+	return nil
+}
+
+// Choices restricts the argument to the given values, e.g. "json", "text".
+// Each element of a slice argument is checked on its own.
+func (a *Argument) Choices(choices ...string) *Argument {
+	a.choices = choices
+	return a
+}
+
+// checkChoice reports an error when value is not one of the argument's choices
+func (a *Argument) checkChoice(value interface{}) error {
+	if len(a.choices) == 0 {
+		return nil
+	}
+
+	items := []string{}
+	switch v := value.(type) {
+	case []string:
+		items = v
+	case []int:
+		for _, item := range v {
+			items = append(items, strconv.Itoa(item))
+		}
+	default:
+		items = append(items, fmt.Sprint(v))
+	}
+
+	for _, item := range items {
+		if !slices.Contains(a.choices, item) {
+			return fmt.Errorf("invalid value for argument '%s': must be one of %s", a.Name, strings.Join(a.choices, ", "))
+		}
+	}
+	return nil
+}
+
+// Metavar sets the value placeholder shown in help and usage, e.g. "FILE" for
+// "--config FILE". Without one the data type name is used.
+func (a *Argument) Metavar(metavar string) *Argument {
+	a.metavar = metavar
+	return a
+}
+
+// Implies sets other arguments when this one is passed, e.g. --debug implying
+// {"verbose": true, "log-level": "debug"}. A value the user passes explicitly
+// always wins over an implied one.
+func (a *Argument) Implies(values map[string]interface{}) *Argument {
+	a.implies = values
+	return a
+}
+
+// OnSet registers a callback run as soon as the argument is parsed, in
+// command line order, e.g. to build an ordered filter chain from repeated
+// flags. Returning an error aborts parsing with that error.
+func (a *Argument) OnSet(callback func(value interface{}) error) *Argument {
+	a.onSet = callback
+	return a
+}
+
+// LinkTo stores the argument's value under another argument's name, so two
+// flags can feed one value, e.g. a -v count and a --verbosity int both setting
+// "verbosity". Whichever flag appears last on the command line wins, and the
+// target argument's default applies when neither is passed.
+func (a *Argument) LinkTo(name string) *Argument {
+	a.linkTo = name
+	return a
+}
+
+// ReplacedBy marks the argument as renamed to the argument newName. Using the
+// old flag still works: it sets the new argument's value and records a
+// deprecation warning. The old flag is no longer listed in help.
+func (a *Argument) ReplacedBy(newName string) *Argument {
+	a.linkTo = newName
+	a.replaced = true
+	return a
+}
+
+// key returns the name the argument's value is stored under
+func (a *Argument) key() string {
+	if a.linkTo != "" {
+		return a.linkTo
+	}
+	return a.Name
+}
+
+// OptionalValue lets a valued argument be passed without a value. When the
+// flag is last or followed by another flag, it is set to defaultWhenBare,
+// e.g. --log alone logs to a default path while --log custom.txt overrides it.
+func (a *Argument) OptionalValue(defaultWhenBare interface{}) *Argument {
+	a.bareValue = defaultWhenBare
+	a.optionalValue = true
+	return a
+}
+
+// Example attaches a usage example shown beneath the argument in help,
+// e.g. arg.Example("--filter 'status=active'").
+func (a *Argument) Example(example string) *Argument {
+	a.example = example
+	return a
+}
+
+// Nargs makes a slice argument consume exactly n values, e.g. --size WIDTH HEIGHT,
+// rather than greedily taking every following non-flag token.
+func (a *Argument) Nargs(n int) *Argument {
+	a.nargs = n
+	return a
+}
+
+// AllowFileValue lets the argument read its value from a file when the value
+// is given as @path, e.g. --token @/run/secrets/token.
+func (a *Argument) AllowFileValue() *Argument {
+	a.fileValue = true
+	return a
+}
+
+// resolveValue returns the raw value for the argument, replacing @path with
+// the trimmed contents of the file when AllowFileValue is set.
+func (a *Argument) resolveValue(rawValue string) (string, error) {
+	if !a.fileValue || !strings.HasPrefix(rawValue, "@") {
+		return rawValue, nil
+	}
+
+	contents, err := os.ReadFile(rawValue[1:])
+	if err != nil {
+		return "", fmt.Errorf("could not read value for argument '%s': %v", a.Name, err)
+	}
+	return strings.TrimSpace(string(contents)), nil
 }
 
 type ExclusiveGroup struct {
 	Options 		[]string	// Names of mutually exclusive options
 	MustHave 		bool		// If true, exactly one option must be provided
+	Default			string		// Option selected when none is given, if set
+	Title			string		// Label of the group's block in help, if set
 }
 
 // Option represents a functional option for configuring the parser with program metadata
@@ -36,6 +274,51 @@ type Parser struct {
 	Version			string // (Optional) Program version
 	args			[]*Argument
 	exclusiveGroups	[]*ExclusiveGroup	
+	allowPositional	bool
+	positional		[]string
+	helpStyle		string
+	helpOrder		string
+	invocationName	string
+	requireAnyGroups	[][]string
+	conflicts		[][2]string
+	atLeast			[]atLeastRule
+	examples		[][2]string	// Command line and description pairs from AddExample
+	stopAtFirstUnknown	bool
+	contextualErrors	bool
+	allowEmptyArgs	bool
+	groups			[]*ArgumentGroup
+	warnings		[]string
+	warningsAsErrors	bool
+	autoConfig		string	// Name of the argument holding a config file path
+	flexibleNames	bool
+	ignoreCase		bool
+	minPositional	int
+	maxPositional	int	// Negative means no upper bound
+	output			io.Writer
+	errOutput		io.Writer
+	translator		func(key string) string
+	versionTemplate	string
+	versionFields	map[string]string
+	envPrefix		string
+	pairs			map[string][]KeyValue	// Ordered pairs given to map arguments in the last Parse
+	raw				map[string][]string		// Value tokens as typed in the last Parse, before conversion
+	quiet			bool
+	exitFunc		func(code int)
+	ttyOverride		bool
+	forceTTY		bool
+	commands		[]*Parser
+	commandSuggestions	bool
+	errorMode		string	// "fast" (the default) or "aggregate"
+	strictDashes	bool
+	withoutDefaults	bool
+	parseDebug		io.Writer
+	sliceDefaultFormat	func(values []string) string
+	errs			[]error	// Problems found by the current Parse
+	bindings		[]binding	// Variables registered with StringVar and friends
+	parent			*Parser
+	remaining		[]string
+	provided		map[string]bool	// Names of arguments explicitly passed in the last Parse
+	external		map[string]bool	// Names of arguments set from the environment or a config file in the last Parse
 }
 
 
@@ -66,11 +349,228 @@ func WithVersion(version string) Option {
 		p.Version = version
 	}
 }
+
+// WithAllowPositional collects tokens that are neither flags nor flag values
+// instead of rejecting them as unknown arguments. Retrieve them with Positional.
+func WithAllowPositional() Option {
+	return func(p *Parser) {
+		p.allowPositional = true
+	}
+}
+
+// WithUnknownAsPositional stores unrecognized tokens that don't start with "-"
+// as positional arguments while still rejecting unknown flags, so arbitrary
+// file arguments are accepted without hiding flag typos. It is equivalent to
+// WithAllowPositional.
+func WithUnknownAsPositional() Option {
+	return WithAllowPositional()
+}
+
+// WithStopAtFirstUnknown halts parsing at the first non-flag token instead of
+// erroring, leaving it and everything after it available through Remaining.
+// This takes precedence over WithAllowPositional.
+func WithStopAtFirstUnknown() Option {
+	return func(p *Parser) {
+		p.stopAtFirstUnknown = true
+	}
+}
+
+// WithContextualErrors appends the help lines of the arguments involved in a
+// failed group rule to the returned error, instead of leaving users to dig
+// through the full help output.
+func WithContextualErrors() Option {
+	return func(p *Parser) {
+		p.contextualErrors = true
+	}
+}
+
+// WithAllowEmptyArgs parses an empty argument list normally, applying defaults
+// and checking required arguments, instead of printing help and signalling exit.
+func WithAllowEmptyArgs() Option {
+	return func(p *Parser) {
+		p.allowEmptyArgs = true
+	}
+}
+
+// WithWarningsAsErrors makes Parse fail when any warning was collected
+func WithWarningsAsErrors() Option {
+	return func(p *Parser) {
+		p.warningsAsErrors = true
+	}
+}
+
+// WithFlexibleFlagNames matches long flags regardless of dashes and
+// underscores, so --retry_count and --retrycount both match --retry-count.
+// With ignoreCase set, --Retry-Count matches as well.
+func WithFlexibleFlagNames(ignoreCase bool) Option {
+	return func(p *Parser) {
+		p.flexibleNames = true
+		p.ignoreCase = ignoreCase
+	}
+}
+
+// WithOutput sets where help and version output is written (default os.Stdout)
+func WithOutput(w io.Writer) Option {
+	return func(p *Parser) {
+		p.output = w
+	}
+}
+
+// WithErrorOutput sets where error-triggered help is written (default os.Stderr)
+func WithErrorOutput(w io.Writer) Option {
+	return func(p *Parser) {
+		p.errOutput = w
+	}
+}
+
+// WithTranslator translates help output. It is called with static labels such
+// as "Usage", "Author", "Version", "Example" and group titles, and with
+// "description:<name>" for each argument; returning the key unchanged keeps
+// the original description.
+func WithTranslator(translator func(key string) string) Option {
+	return func(p *Parser) {
+		p.translator = translator
+	}
+}
+
+// WithVersionTemplate formats version output with text/template. The template
+// can use {{.Name}}, {{.Version}}, {{.Author}} and any WithVersionField keys.
+func WithVersionTemplate(tmpl string) Option {
+	return func(p *Parser) {
+		p.versionTemplate = tmpl
+	}
+}
+
+// WithVersionField adds a key/value, such as a build commit or date, that the
+// version template can reference as {{.key}}.
+func WithVersionField(key, value string) Option {
+	return func(p *Parser) {
+		if p.versionFields == nil {
+			p.versionFields = map[string]string{}
+		}
+		p.versionFields[key] = value
+	}
+}
+
+// WithEnvPrefix reads arguments not given on the command line from environment
+// variables named PREFIX_NAME, e.g. MYPROG_LOG_LEVEL for "log-level". Slice
+// values are comma-separated. Precedence is command line, environment, config
+// file, then built-in defaults.
+func WithEnvPrefix(prefix string) Option {
+	return func(p *Parser) {
+		p.envPrefix = prefix
+	}
+}
+
+// WithQuiet stops Parse from printing help and version output itself. The
+// result still reports HelpRequested/VersionRequested and ShouldExit so the
+// caller can render it, e.g. with Help or VersionString.
+func WithQuiet() Option {
+	return func(p *Parser) {
+		p.quiet = true
+	}
+}
+
+// WithExitFunc replaces os.Exit in MustParse, e.g. with a recording or
+// panicking function in tests.
+func WithExitFunc(exit func(code int)) Option {
+	return func(p *Parser) {
+		p.exitFunc = exit
+	}
+}
+
+// WithForceTTY overrides terminal detection for help output. Help written to
+// a terminal uses the aligned layout, anything else gets plain unaligned lines.
+func WithForceTTY(isTTY bool) Option {
+	return func(p *Parser) {
+		p.ttyOverride = true
+		p.forceTTY = isTTY
+	}
+}
+
+// WithCommandSuggestions adds a "did you mean" hint to unknown command errors
+// when a registered command is a close match, e.g. 'deloy' for 'deploy'.
+func WithCommandSuggestions() Option {
+	return func(p *Parser) {
+		p.commandSuggestions = true
+	}
+}
+
+// WithErrorMode controls whether parsing stops at the first problem ("fast",
+// the default) or collects unknown arguments and every validation failure
+// ("aggregate") into one *ParseErrors, whose Errors method lists them.
+func WithErrorMode(mode string) Option {
+	return func(p *Parser) {
+		p.errorMode = mode
+	}
+}
+
+// WithStrictDashes rejects a long flag typed with a single dash, e.g. -config,
+// with a hint to use --config, instead of reading it as a cluster of short flags.
+func WithStrictDashes() Option {
+	return func(p *Parser) {
+		p.strictDashes = true
+	}
+}
+
+// WithoutDefaults leaves arguments that were not given out of the parsed map
+// instead of filling in their defaults, zero bools and zero counts. Only
+// values from the command line, environment, config file and stdin are
+// present, so callers can layer their own config but must handle missing
+// keys. Validation is unchanged: an exclusive group's Default still satisfies
+// its group and other rules, it just isn't put in the map.
+func WithoutDefaults() Option {
+	return func(p *Parser) {
+		p.withoutDefaults = true
+	}
+}
+
+// WithSliceDefaultFormat sets how slice defaults are rendered in help, e.g. as
+// a JSON array or space separated. By default they are comma-joined.
+func WithSliceDefaultFormat(format func(values []string) string) Option {
+	return func(p *Parser) {
+		p.sliceDefaultFormat = format
+	}
+}
+
+// WithHelpOrder sets the order of arguments in help: "alpha" (the default)
+// sorts by name, "declared" keeps the order they were added in, and
+// "required-first" lists required arguments before optional ones, each sorted
+// by name.
+func WithHelpOrder(order string) Option {
+	return func(p *Parser) {
+		p.helpOrder = order
+	}
+}
+
+// WithInvocationName sets the command shown in usage lines and completion
+// scripts, e.g. "sample-cli" when Name is a display title such as "Sample CLI
+// Program". It defaults to the base name of os.Args[0].
+func WithInvocationName(name string) Option {
+	return func(p *Parser) {
+		p.invocationName = name
+	}
+}
+
+// WithHelpStyle selects how PrintHelp lays out the argument list:
+// "aligned" (default) pads flags into a two-column table when writing to a
+// terminal, "compact" prints one unaligned line per flag for CLIs with many
+// arguments.
+func WithHelpStyle(style string) Option {
+	return func(p *Parser) {
+		p.helpStyle = style
+	}
+}
+
 // NewParser creates a new instance of the argument parser
 func NewParser(options ...Option) *Parser {
 	p := &Parser {
 		args:				[]*Argument{},
 		exclusiveGroups:	[]*ExclusiveGroup{},
+		maxPositional:		-1,
+		output:				os.Stdout,
+		errOutput:			os.Stderr,
+		exitFunc:			os.Exit,
 	}
 
 	// Apply all optionally provided function options
@@ -93,217 +593,1389 @@ func (p *Parser) AddArgument(name, short, long, description string, dataType str
 	}
 
 	if len(defaultValue) > 0 {
-		arg.DefaultValue = defaultValue[0]
+		arg.DefaultValue, arg.defaultErr = convertDefault(dataType, defaultValue[0])
 	}
 	p.args = append(p.args, arg)
 	return arg
 }
 
-func (p *Parser) AddExclusiveGroup(optionNames []string, mustHave bool) {
-	p.exclusiveGroups = append(p.exclusiveGroups, &ExclusiveGroup{
+// convertDefault stores untyped numeric constants in the argument's type, so
+// a float32 argument declared with default 0.5 yields a float32 either way,
+// and parses duration defaults given as strings such as "30s". An invalid
+// duration is reported by Validate.
+func convertDefault(dataType string, value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case int:
+		switch dataType {
+		case "float64":
+			return float64(v), nil
+		case "float32":
+			return float32(v), nil
+		}
+	case float64:
+		if dataType == "float32" {
+			return float32(v), nil
+		}
+	case string:
+		if dataType == "duration" {
+			duration, err := time.ParseDuration(v)
+			if err != nil {
+				return value, fmt.Errorf("expected a duration (e.g. 300ms, 1.5h), got '%s'", v)
+			}
+			return duration, nil
+		}
+	}
+	return value, nil
+}
+
+// AddExclusiveGroup allows at most one of the named options to be passed, or
+// exactly one when mustHave is set. Only options given on the command line
+// count, so members that merely fall back to their defaults never conflict.
+// The returned group's Default can name the member selected when none is given,
+// and its Title labels the members' block in help.
+func (p *Parser) AddExclusiveGroup(optionNames []string, mustHave bool) *ExclusiveGroup {
+	group := &ExclusiveGroup{
 		Options: 		optionNames,
 		MustHave:		mustHave,
-	})
+	}
+	p.exclusiveGroups = append(p.exclusiveGroups, group)
+	return group
 }
 
-func (p *Parser) validateExclusiveGroups(parsedArgs map[string]interface{}) error {
-	for _, group := range p.exclusiveGroups {
-		foundCount := 0
+// AddRequireAnyGroup requires at least one of the named options to be passed,
+// without forbidding several of them being passed together.
+func (p *Parser) AddRequireAnyGroup(optionNames []string) {
+	p.requireAnyGroups = append(p.requireAnyGroups, optionNames)
+}
 
-		// Count how many mutually exclusive options are passed
-		for _, optionName := range group.Options {
-			if _, exists := parsedArgs[optionName]; exists {
-				foundCount++
-			}
-		}
+// AddExample adds a full example invocation to the "Examples:" section of help,
+// e.g. AddExample("mytool --input data.csv --format json", "Convert a file").
+func (p *Parser) AddExample(command, description string) {
+	p.examples = append(p.examples, [2]string{command, description})
+}
 
-		// If more than one option in the group is passed, it's an error
-		if foundCount > 1 {
-			return fmt.Errorf("mutually exclusive options passed: %v", group.Options)
-		}
+// AddConflict forbids passing the two named arguments together, while either
+// one alone (or neither) is fine. It is shorthand for a two-member exclusive
+// group with a clearer error, e.g. "--fast conflicts with --thorough".
+func (p *Parser) AddConflict(a, b string) {
+	p.conflicts = append(p.conflicts, [2]string{a, b})
+}
 
-		// If 'mustHave' is true but none were provided
-		if group.MustHave && foundCount == 0 {
-			return fmt.Errorf("one of the mutually exlusive options must be provided: %v", group.Options)
+func (p *Parser) validateConflicts() error {
+	for _, conflict := range p.conflicts {
+		if p.given(conflict[0]) && p.given(conflict[1]) {
+			err := fmt.Errorf("%s conflicts with %s", p.displayName(conflict[0]), p.displayName(conflict[1]))
+			return p.contextualError(err, conflict[:])
 		}
 	}
 	return nil
 }
 
-func parseArguments(defs []*Argument, args []string, parsedArgs map[string]interface{}) error {
-    for i := 0; i < len(args); i++ {
-        arg := args[i]
-
-        // Handle stacked short form flags (e.g., -abc => -a -b -c)
-        if strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && len(arg) > 2 {
-            for j := 1; j < len(arg); j++ {
-                shortFlag := string(arg[j])
-                found := false
-                
-                // Look for the short flag definition
-                for _, def := range defs {
-                    if def.Short == shortFlag && def.DataType == "bool" {
-                        parsedArgs[def.Name] = true
-                        found = true
-                        break
-                    }
-                }
-
-                if !found {
-                    return fmt.Errorf("unknown argument: -%s", shortFlag)
-                }
-            }
-            continue // Move to the next argument since a stacked group was processed
-        }
-
-        // Handle normal (non-stacked) flags
-        found := false
-        for _, def := range defs {
-            if arg == "-"+def.Short || arg == "--"+def.Long {
-                found = true
+func (p *Parser) validateRequireAnyGroups() error {
+	for _, group := range p.requireAnyGroups {
+		found := false
+		flags := []string{}
+		for _, optionName := range group {
+			if p.given(optionName) {
+				found = true
+			}
+			flags = append(flags, p.displayName(optionName))
+		}
 
-                if def.DataType == "bool" {
-                    parsedArgs[def.Name] = true
-                    break
-                }
+		if !found {
+			return p.contextualError(fmt.Errorf("at least one of %s is required", strings.Join(flags, ", ")), group)
+		}
+	}
+	return nil
+}
 
-                // Ensure non-boolean flags have a value following them
-                if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
-                    rawValue := args[i+1]
-                    i++
+// atLeastRule is a RequireAtLeast threshold
+type atLeastRule struct {
+	n		int
+	names	[]string
+}
 
-                    switch def.DataType {
-                    case "int":
-                        intValue, err := strconv.Atoi(rawValue)
-                        if err != nil {
-                            return fmt.Errorf("invalid value for argument '%s': expected an integer", def.Name)
-                        }
-                        parsedArgs[def.Name] = intValue
-                    case "string":
-                        parsedArgs[def.Name] = rawValue
-                    case "[]string":
-                        values := []string{rawValue}
-                        for i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
-                            values = append(values, args[i+1])
-                            i++
-                        }
-                        parsedArgs[def.Name] = values
-                    default:
-                        return fmt.Errorf("unknown data type '%s' for argument '%s'", def.DataType, def.Name)
-                    }
-                } else {
-                    return fmt.Errorf("no value provided for argument %s", arg)
-                }
-            }
-        }
+// RequireAtLeast requires at least n of the named options to be passed, e.g.
+// two of four optional-but-need-some flags. RequireAtLeast(1, ...) is the
+// same as AddRequireAnyGroup.
+func (p *Parser) RequireAtLeast(n int, names ...string) {
+	p.atLeast = append(p.atLeast, atLeastRule{n: n, names: names})
+}
 
-        if !found {
-            return fmt.Errorf("unknown argument: %s", arg)
-        }
-    }
+func (p *Parser) validateAtLeast() error {
+	for _, rule := range p.atLeast {
+		count := 0
+		for _, name := range rule.names {
+			if p.given(name) {
+				count++
+			}
+		}
+		if count < rule.n {
+			return p.contextualError(fmt.Errorf("at least %d of %v must be provided, got %d", rule.n, rule.names, count), rule.names)
+		}
+	}
+	return nil
+}
 
-    // Handle defaults after parsing
-    for _, def := range defs {
-        if _, ok := parsedArgs[def.Name]; !ok {
-            if def.DefaultValue != nil {
-                parsedArgs[def.Name] = def.DefaultValue
-            } else if def.DataType == "bool" {
-                parsedArgs[def.Name] = false
-            }
-        }
-    }
+// given reports whether the named argument was passed on the command line or
+// set from the environment or a config file, which is what the group and
+// conflict rules count. Defaults do not.
+func (p *Parser) given(name string) bool {
+	return p.provided[name] || p.external[name]
+}
 
-    return nil
+// displayName returns the flag form users type for the named argument,
+// preferring --long over -short and falling back to the name itself.
+func (p *Parser) displayName(name string) string {
+	arg := p.lookupArgument(name)
+	switch {
+	case arg == nil:
+		return name
+	case arg.Long != "":
+		return "--" + arg.Long
+	case arg.Short != "":
+		return "-" + arg.Short
+	}
+	return name
 }
 
-// Parse the CLI arguments
-func (p *Parser) Parse() (map[string]interface{}, bool, error) {
-	args := os.Args[1:]
+// Validate checks the parser definition for contradictions that would make
+// every invocation fail. Parse calls it before processing any arguments.
+func (p *Parser) Validate() error {
+	for _, group := range p.exclusiveGroups {
+		required := []string{}
+		for _, optionName := range group.Options {
+			if arg := p.lookupArgument(optionName); arg != nil && arg.Required {
+				required = append(required, optionName)
+			}
+		}
 
-	// Handle "help" request or no arguments passed cases
-	if len(args) == 0 || containsHelpArgument(args) {
-		p.PrintHelp()
-		return nil, true, nil
+		// At most one member of the group can ever be passed
+		if len(required) > 1 {
+			return fmt.Errorf("arguments in exclusive group cannot all be required: %v", required)
+		}
+		if group.Default != "" && !slices.Contains(group.Options, group.Default) {
+			return fmt.Errorf("default '%s' is not a member of exclusive group %v", group.Default, group.Options)
+		}
 	}
 
-	if requestedVersion(args) {
-		p.PrintVersion()
-		return nil, true, nil
+	if p.autoConfig != "" {
+		if arg := p.lookupArgument(p.autoConfig); arg == nil || arg.DataType != "string" {
+			return fmt.Errorf("AutoConfig names '%s', which is not a string argument", p.autoConfig)
+		}
 	}
 
-	// Parse the individual arguments based on p.args and command structure
-	parsedArgs := map[string]interface{}{}
-
-	// Parse global arguments using helper parseArguments func
-	err := parseArguments(p.args, args, parsedArgs)
-	if err != nil {
-		if strings.HasPrefix(err.Error(), "unknown argument") {
-			return nil, true, fmt.Errorf("unknown argument: %s", args[0])
+	// Command values are merged into the global ones, so names must not clash
+	for _, cmd := range p.commands {
+		for _, arg := range cmd.args {
+			if p.lookupArgument(arg.Name) != nil {
+				return fmt.Errorf("argument '%s' of command '%s' has the same name as a global argument", arg.Name, cmd.Name)
+			}
 		}
-		return nil, true, err
 	}
 
-	// Validate global required args after parsing all subcommands
-	for _, arg := range p.args {
-		if arg.Required {
-			if _, ok := parsedArgs[arg.Name]; !ok {
-				return nil, true, fmt.Errorf("missing required global argument: %s", arg.Name)
+	for _, rule := range p.atLeast {
+		if rule.n > len(rule.names) {
+			return fmt.Errorf("cannot require at least %d of %v", rule.n, rule.names)
+		}
+		for _, name := range rule.names {
+			if p.lookupArgument(name) == nil {
+				return fmt.Errorf("RequireAtLeast references unknown argument '%s'", name)
 			}
 		}
 	}
 
-	// Validate mutual exclusivity
-	err = p.validateExclusiveGroups(parsedArgs)
-	if err != nil {
-		return nil, true, err
+	for _, conflict := range p.conflicts {
+		for _, name := range conflict {
+			if p.lookupArgument(name) == nil {
+				return fmt.Errorf("conflict references unknown argument '%s'", name)
+			}
+		}
+	}
+
+	switch p.helpOrder {
+	case "", "alpha", "declared", "required-first":
+	default:
+		return fmt.Errorf("unknown help order '%s': expected alpha, declared or required-first", p.helpOrder)
 	}
 
-	return parsedArgs, false, nil
+	if p.errorMode != "" && p.errorMode != "fast" && p.errorMode != "aggregate" {
+		return fmt.Errorf("unknown error mode '%s': expected fast or aggregate", p.errorMode)
+	}
+
+	if p.versionTemplate != "" {
+		if _, err := template.New("version").Parse(p.versionTemplate); err != nil {
+			return fmt.Errorf("invalid version template: %v", err)
+		}
+	}
+
+	for _, arg := range p.args {
+		// Nothing on the command line could ever match such an argument, unless
+		// it is only meant to be set from the environment
+		if arg.Short == "" && arg.Long == "" && arg.source != "env" {
+			return fmt.Errorf("argument '%s' must define a short or long flag", arg.Name)
+		}
+		// Stacked flag parsing splits clusters byte by byte, which would break
+		// apart multi-character and non-ASCII shorts
+		if arg.Short != "" && !isASCIIShort(arg.Short) {
+			return fmt.Errorf("short flag for argument '%s' must be a single ASCII character: -%s", arg.Name, arg.Short)
+		}
+		// Bools honor a true/false default; anything else would be silently misread
+		if _, ok := arg.DefaultValue.(bool); arg.DataType == "bool" && arg.DefaultValue != nil && !ok {
+			return fmt.Errorf("default value for bool argument '%s' must be true or false, got %v", arg.Name, arg.DefaultValue)
+		}
+		if arg.source == "env" && p.envPrefix == "" {
+			return fmt.Errorf("argument '%s' is environment-only but the parser has no WithEnvPrefix", arg.Name)
+		}
+		if arg.fromStdin && (strings.HasPrefix(arg.DataType, "[]") || arg.DataType == "map[string]string") {
+			return fmt.Errorf("argument '%s' reads from stdin but is not a single-value type", arg.Name)
+		}
+		if arg.defaultErr != nil {
+			return fmt.Errorf("invalid default for argument '%s': %v", arg.Name, arg.defaultErr)
+		}
+		if arg.patternErr != nil {
+			return fmt.Errorf("invalid pattern for argument '%s': %v", arg.Name, arg.patternErr)
+		}
+		if arg.linkTo != "" && p.lookupArgument(arg.linkTo) == nil {
+			return fmt.Errorf("argument '%s' links to unknown argument '%s'", arg.Name, arg.linkTo)
+		}
+		for _, name := range arg.onlyFor {
+			if p.lookupCommand(name) == nil {
+				return fmt.Errorf("argument '%s' is restricted to unknown command '%s'", arg.Name, name)
+			}
+		}
+		for _, name := range []string{arg.requiredUnless, arg.requiredIf} {
+			if name != "" && p.lookupArgument(name) == nil {
+				return fmt.Errorf("argument '%s' depends on unknown argument '%s'", arg.Name, name)
+			}
+		}
+		for name := range arg.implies {
+			if p.lookupArgument(name) == nil {
+				return fmt.Errorf("argument '%s' implies unknown argument '%s'", arg.Name, name)
+			}
+		}
+		if arg.nargs > 0 && !strings.HasPrefix(arg.DataType, "[]") {
+			return fmt.Errorf("argument '%s' sets Nargs but is not a slice type", arg.Name)
+		}
+	}
+	return nil
 }
 
-// PrintVersion does the obvious
-func(p *Parser) PrintVersion() {
-	if p.Version != "" {
-		fmt.Printf("%s Version: %s\n", p.Name, p.Version)
-	} else {
-		fmt.Println("No version information provided by program.")
+// lookupArgument returns the argument definition with the given name, or nil
+func (p *Parser) lookupArgument(name string) *Argument {
+	for _, arg := range p.args {
+		if arg.Name == name {
+			return arg
+		}
 	}
+	return nil
 }
 
+// applyGroupDefaults selects the Default member of each exclusive group none of
+// whose members were set. The default counts as provided, so it satisfies
+// require-any, at-least and required checks like a member given by the user.
+// A bool default is set to true; any other default takes its DefaultValue.
+// It returns the names of the selected members.
+func (p *Parser) applyGroupDefaults(parsedArgs map[string]interface{}) []string {
+	selected := []string{}
+	for _, group := range p.exclusiveGroups {
+		if group.Default == "" {
+			continue
+		}
+		set := false
+		for _, optionName := range group.Options {
+			if _, ok := parsedArgs[optionName]; ok {
+				set = true
+			}
+		}
+		arg := p.lookupArgument(group.Default)
+		if set || arg == nil {
+			continue
+		}
 
-// PrintHelp does the obvious
-func (p *Parser) PrintHelp() {
-	// Optional program metadata
-	if p.Name != "" {
-		fmt.Printf("%s\n", p.Name)
+		if arg.DataType == "bool" {
+			parsedArgs[arg.Name] = true
+		} else if arg.DefaultValue != nil {
+			parsedArgs[arg.Name] = arg.DefaultValue
+		}
+		p.provided[arg.Name] = true
+		selected = append(selected, arg.Name)
 	}
-	if p.Author != "" {
-		fmt.Printf("Author: %s\n", p.Author)
+	return selected
+}
+
+// isASCIIShort reports whether short is one ASCII character
+func isASCIIShort(short string) bool {
+	return len(short) == 1 && short[0] < utf8.RuneSelf
+}
+
+// validateConditionalRequired applies RequiredUnless and RequiredIf. It runs
+// before defaults are applied, so a value from any source counts as set, and
+// a bool only counts when true.
+func (p *Parser) validateConditionalRequired(parsedArgs map[string]interface{}) error {
+	isSet := func(name string) bool {
+		value, ok := parsedArgs[name]
+		return ok && value != false
 	}
-	if p.Version != "" {
-		fmt.Printf("Version: %s\n", p.Version)
+
+	for _, arg := range p.args {
+		if isSet(arg.key()) || arg.DefaultValue != nil || arg.defaultFunc != nil {
+			continue
+		}
+		if arg.requiredUnless != "" && !isSet(arg.requiredUnless) {
+			err := fmt.Errorf("%s is required unless %s is set", p.displayName(arg.Name), p.displayName(arg.requiredUnless))
+			return p.contextualError(err, []string{arg.Name, arg.requiredUnless})
+		}
+		if arg.requiredIf != "" && isSet(arg.requiredIf) {
+			err := fmt.Errorf("%s is required when %s is set", p.displayName(arg.Name), p.displayName(arg.requiredIf))
+			return p.contextualError(err, []string{arg.Name, arg.requiredIf})
+		}
 	}
-	if p.Description != "" {
-		fmt.Printf("%s\n", p.Description)
+	return nil
+}
+
+// validateExclusiveGroups checks each group on its own, so an argument that
+// belongs to several overlapping groups is counted once in each of them.
+func (p *Parser) validateExclusiveGroups(parsedArgs map[string]interface{}) error {
+	seen := map[string]bool{}
+	for _, group := range p.exclusiveGroups {
+		// The same set of options registered twice is only checked once
+		members := append([]string{}, group.Options...)
+		sort.Strings(members)
+		signature := strings.Join(members, "\x00")
+		if seen[signature] {
+			continue
+		}
+		seen[signature] = true
+
+		// Collect the mutually exclusive options that were passed. Defaults are
+		// already in parsedArgs, so only options given explicitly count.
+		found := []string{}
+		for _, optionName := range group.Options {
+			if p.given(optionName) {
+				found = append(found, optionName)
+			}
+		}
+
+		// If more than one option in the group is passed, it's an error
+		if len(found) > 1 {
+			err := fmt.Errorf("only one of %v allowed, but %s were all provided", group.Options, strings.Join(found, ", "))
+			return p.contextualError(err, group.Options)
+		}
+
+		// If 'mustHave' is true but none were provided
+		if group.MustHave && len(found) == 0 {
+			return p.contextualError(fmt.Errorf("one of the mutually exlusive options must be provided: %v", group.Options), group.Options)
+		}
 	}
+	return nil
+}
+
+func (p *Parser) parseArguments(defs []*Argument, args []string, parsedArgs map[string]interface{}) error {
+    index := p.buildFlagIndex(defs)
+
+    for i := 0; i < len(args); i++ {
+        arg := args[i]
+
+        // A lone "--" ends flag parsing and everything after it is taken
+        // literally, e.g. rm -- -file. Slice values stop before it too.
+        if arg == "--" {
+            if p.allowPositional {
+                p.positional = append(p.positional, args[i+1:]...)
+            } else {
+                p.remaining = args[i+1:]
+            }
+            break
+        }
+
+        // Hand back the rest of the arguments, e.g. for an external subcommand
+        if p.stopAtFirstUnknown && !strings.HasPrefix(arg, "-") {
+            p.remaining = args[i:]
+            break
+        }
+
+        // Collect non-flag tokens when positional arguments are allowed
+        if p.allowPositional && !strings.HasPrefix(arg, "-") {
+            p.positional = append(p.positional, arg)
+            continue
+        }
+
+        // A common slip is -config for --config, which would otherwise be read
+        // as the cluster -c -o -n -f -i -g
+        if p.strictDashes && strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && len(arg) > 2 {
+            name, _, _ := strings.Cut(arg[1:], "=")
+            if p.lookupFlag(index, "--"+name) != nil {
+                return fmt.Errorf("unknown argument: -%s; did you mean --%s?", name, name)
+            }
+        }
+
+        // Handle stacked short form flags (e.g., -abc => -a -b -c)
+        attached, hasAttached := "", false
+        if strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && len(arg) > 2 {
+            valuedShort := ""
+            for j := 1; j < len(arg); j++ {
+                // Punctuation such as the dashes in -v-- can't be a short flag
+                if !unicode.IsLetter(rune(arg[j])) && !unicode.IsDigit(rune(arg[j])) {
+                    return fmt.Errorf("malformed flag cluster: %s", arg)
+                }
+                shortFlag := string(arg[j])
+                found := false
+                
+                // Look for the short flag definition
+                if def := index["-"+shortFlag]; def != nil {
+                    switch {
+                    case def.DataType == "bool":
+                        p.warnReplaced("-"+shortFlag, def)
+                        if err := setValue(def, parsedArgs, true); err != nil {
+                            return err
+                        }
+                        p.recordRaw(def, nil)
+                        found = true
+                    case def.DataType == "count":
+                        p.warnReplaced("-"+shortFlag, def)
+                        if err := setValue(def, parsedArgs, nextCount(parsedArgs, def.key())); err != nil {
+                            return err
+                        }
+                        p.recordRaw(def, nil)
+                        found = true
+                    case j == len(arg)-1:
+                        // A valued flag may end the cluster and take the next token (-vf out.txt)
+                        valuedShort = shortFlag
+                        found = true
+                    case arg[j+1] != '=' && allShortFlags(index, arg[j+1:]):
+                        // -ab with -b a flag of its own could mean either -a b or
+                        // -a -b, so the valued flag has to come last or use -a=b
+                        return fmt.Errorf("-%s takes a value and cannot appear before other flags in a cluster", shortFlag)
+                    default:
+                        // Otherwise it takes the rest of the cluster as its value (-n5, -n=5)
+                        valuedShort = shortFlag
+                        attached, hasAttached = strings.TrimPrefix(arg[j+1:], "="), true
+                        found = true
+                    }
+                }
+
+                if !found {
+                    // Name the whole token as well, e.g. -x in -xvalue
+                    err := fmt.Errorf("unknown argument: -%s (in %s)", shortFlag, arg)
+                    if p.errorMode != "aggregate" {
+                        return err
+                    }
+                    p.errs = append(p.errs, err)
+                }
+                if hasAttached {
+                    break
+                }
+            }
+            if valuedShort == "" {
+                continue // Move to the next argument since a stacked group was processed
+            }
+            arg = "-" + valuedShort
+        }
+
+        // Handle normal (non-stacked) flags, splitting --name=value on the first "="
+        flag, inlineValue, hasInline := arg, attached, hasAttached
+        if strings.HasPrefix(arg, "--") {
+            if name, value, ok := strings.Cut(arg, "="); ok {
+                flag, inlineValue, hasInline = name, value, true
+            }
+        }
+
+        def := p.lookupFlag(index, flag)
+        found := def != nil
+        if found {
+            p.warnReplaced(flag, def)
+        }
+
+        if found && def.DataType == "count" && hasInline {
+            return fmt.Errorf("argument %s does not take a value", flag)
+        }
+
+        // Bools only take a value attached with "=" (--verbose=false) and never
+        // consume the next token, so --verbose false leaves "false" alone
+        if found && def.DataType == "bool" {
+            value := true
+            if hasInline {
+                boolValue, err := strconv.ParseBool(inlineValue)
+                if err != nil {
+                    return fmt.Errorf("invalid value for %s: expected true or false", flag)
+                }
+                value = boolValue
+                p.recordRaw(def, []string{inlineValue})
+            } else {
+                p.recordRaw(def, nil)
+            }
+            if err := setValue(def, parsedArgs, value); err != nil {
+                return err
+            }
+        } else if found && def.DataType == "count" {
+            if err := setValue(def, parsedArgs, nextCount(parsedArgs, def.key())); err != nil {
+                return err
+            }
+            p.recordRaw(def, nil)
+        } else if found {
+            // Ensure non-boolean flags have a value following them (or attached with "=").
+            // Repeated valued flags are last-wins for every type except maps,
+            // which accumulate pairs (--retry 1 --retry 2 gives 2).
+            flagIndex := i
+            if hasInline || (i+1 < len(args) && isValueToken(def, args[i+1])) {
+                token := inlineValue
+                if !hasInline {
+                    token = args[i+1]
+                    i++
+                }
+                rawValue, err := def.resolveValue(token)
+                if err != nil {
+                    return err
+                }
+
+                switch def.DataType {
+                case "map[string]string":
+                    // Maps accumulate key=value pairs across repeated flags. An
+                    // attached value (--set=a=1) is the whole value.
+                    rawValues := []string{rawValue}
+                    for !hasInline && i+1 < len(args) && isValueToken(def, args[i+1]) {
+                        value, err := def.resolveValue(args[i+1])
+                        if err != nil {
+                            return err
+                        }
+                        rawValues = append(rawValues, value)
+                        i++
+                    }
+                    pairs, err := convertPairs(def, rawValues)
+                    if err != nil {
+                        return err
+                    }
+                    values, _ := parsedArgs[def.key()].(map[string]string)
+                    if values == nil {
+                        values = map[string]string{}
+                    }
+                    for _, pair := range pairs {
+                        values[pair.Key] = pair.Value
+                    }
+                    p.pairs[def.key()] = append(p.pairs[def.key()], pairs...)
+                    if err := setValue(def, parsedArgs, values); err != nil {
+                        return err
+                    }
+                case "[]string", "[]int":
+                    // An attached value (--tags=a) is the whole value, so the
+                    // tokens after it are left alone; --tags= is an empty slice
+                    rawValues := []string{}
+                    if !hasInline || rawValue != "" {
+                        rawValues = append(rawValues, rawValue)
+                        for !hasInline && i+1 < len(args) && isValueToken(def, args[i+1]) {
+                            if def.nargs > 0 && len(rawValues) == def.nargs {
+                                break
+                            }
+                            value, err := def.resolveValue(args[i+1])
+                            if err != nil {
+                                return err
+                            }
+                            rawValues = append(rawValues, value)
+                            i++
+                        }
+                    }
+                    if def.nargs > 0 && len(rawValues) < def.nargs {
+                        return fmt.Errorf("argument '%s' expects %d values, got %d", def.Name, def.nargs, len(rawValues))
+                    }
+                    values, err := convertSlice(def, rawValues)
+                    if err != nil {
+                        return err
+                    }
+                    // Repeated flags add to the slice, so --tags=-a --tags=-b
+                    // can pass values that look like flags
+                    switch previous := parsedArgs[def.key()].(type) {
+                    case []string:
+                        values = append(append([]string{}, previous...), values.([]string)...)
+                    case []int:
+                        values = append(append([]int{}, previous...), values.([]int)...)
+                    }
+                    if err := setValue(def, parsedArgs, values); err != nil {
+                        return err
+                    }
+                default:
+                    value, err := convertValue(def, flag, rawValue)
+                    if err != nil {
+                        return err
+                    }
+                    if err := setValue(def, parsedArgs, value); err != nil {
+                        return err
+                    }
+                }
+
+                tokens := args[flagIndex+1 : i+1]
+                if hasInline {
+                    tokens = append([]string{inlineValue}, tokens...)
+                }
+                p.recordRaw(def, tokens)
+            } else if def.optionalValue {
+                if err := setValue(def, parsedArgs, def.bareValue); err != nil {
+                    return err
+                }
+                p.recordRaw(def, nil)
+            } else {
+                // A value that looks like a flag has to be attached with "="
+                if i+1 < len(args) && strings.HasPrefix(args[i+1], "-") {
+                    err := fmt.Errorf("no value provided for argument %s (to pass %s as its value, use %s=%s)", flag, args[i+1], flag, args[i+1])
+                    return p.pointAt(err, args, i, "expected a value")
+                }
+                return p.pointAt(fmt.Errorf("no value provided for argument %s", flag), args, i, "expected a value")
+            }
+        }
+
+        // --no-<long> turns off a bool flag, e.g. one that defaults to true
+        if !found && strings.HasPrefix(flag, "--no-") {
+            if def := p.lookupFlag(index, "--"+flag[len("--no-"):]); def != nil && def.DataType == "bool" {
+                p.warnReplaced(flag, def)
+                if hasInline {
+                    return fmt.Errorf("argument %s does not take a value", flag)
+                }
+                if err := setValue(def, parsedArgs, false); err != nil {
+                    return err
+                }
+                p.recordRaw(def, nil)
+                found = true
+            }
+        }
 
+        if !found {
+            err := fmt.Errorf("unknown argument: %s", flag)
+            if len(p.commands) > 0 && !strings.HasPrefix(flag, "-") {
+                err = p.unknownCommandError(flag)
+            }
+            // Aggregate mode collects unknown arguments and carries on
+            if p.errorMode != "aggregate" {
+                return err
+            }
+            p.errs = append(p.errs, err)
+        }
+    }
+
+    // Record which arguments were explicitly provided before defaults fill in the
+    // rest, including empty values such as --name ""
+    for _, def := range defs {
+        if _, ok := parsedArgs[def.key()]; ok {
+            p.provided[def.key()] = true
+        }
+    }
+
+    return nil
+}
+
+// buildFlagIndex maps each -short and --long form to its definition so every
+// token is matched with a single lookup, which matters for generated CLIs with
+// hundreds of flags. Long forms are normalized when WithFlexibleFlagNames is
+// set. The first definition of a form wins.
+func (p *Parser) buildFlagIndex(defs []*Argument) map[string]*Argument {
+    index := make(map[string]*Argument, 2*len(defs))
+    for _, def := range defs {
+        forms := []string{}
+        if def.Short != "" {
+            forms = append(forms, "-"+def.Short)
+        }
+        if def.Long != "" {
+            forms = append(forms, p.longKey(def.Long))
+        }
+        for _, form := range forms {
+            if _, ok := index[form]; !ok {
+                index[form] = def
+            }
+        }
+    }
+    return index
+}
 
+// lookupFlag returns the definition matching a -short or --long token, or nil
+func (p *Parser) lookupFlag(index map[string]*Argument, token string) *Argument {
+    if strings.HasPrefix(token, "--") {
+        return index[p.longKey(token[2:])]
+    }
+    return index[token]
+}
 
-	fmt.Println("Usage:")
+// longKey is the index key for a long flag name
+func (p *Parser) longKey(name string) string {
+    if !p.flexibleNames {
+        return "--" + name
+    }
+    return "--" + p.normalizeFlagName(name)
+}
 
-	// Sort arguments by name (or long form if available)
-	sort.Slice(p.args, func(i, j int) bool {
-		return p.args[i].Name < p.args[j].Name
-	})
+// allShortFlags reports whether every character of cluster is a defined short flag
+func allShortFlags(index map[string]*Argument, cluster string) bool {
+    for _, r := range cluster {
+        if index["-"+string(r)] == nil {
+            return false
+        }
+    }
+    return true
+}
+
+// recordRaw keeps the value tokens given for def as typed. Map and slice
+// arguments collect tokens across repeated flags; everything else keeps the
+// last ones.
+func (p *Parser) recordRaw(def *Argument, tokens []string) {
+    if def.DataType == "map[string]string" || strings.HasPrefix(def.DataType, "[]") {
+        p.raw[def.key()] = append(p.raw[def.key()], tokens...)
+        return
+    }
+    p.raw[def.key()] = append([]string{}, tokens...)
+}
+
+// warnReplaced records a deprecation warning when flag belongs to an argument
+// that was renamed with ReplacedBy.
+func (p *Parser) warnReplaced(flag string, def *Argument) {
+    if def.replaced {
+        p.warn("%s is deprecated, use %s instead", flag, p.displayName(def.linkTo))
+    }
+}
+
+func (p *Parser) normalizeFlagName(name string) string {
+    name = strings.NewReplacer("-", "", "_", "").Replace(name)
+    if p.ignoreCase {
+        name = strings.ToLower(name)
+    }
+    return name
+}
+
+// convertValue converts a single raw value to the argument's data type. flag
+// is the token the value was given for and is used in error messages.
+func convertValue(def *Argument, flag string, rawValue string) (interface{}, error) {
+    switch def.DataType {
+    case "int", "count":
+        if rawValue == "" {
+            return nil, fmt.Errorf("invalid value for argument '%s': expected an integer, got empty", def.Name)
+        }
+        intValue, err := parseInt(rawValue)
+        if err != nil {
+            return nil, fmt.Errorf("invalid value for argument '%s': expected an integer", def.Name)
+        }
+        return intValue, nil
+    case "bool":
+        boolValue, err := strconv.ParseBool(rawValue)
+        if err != nil {
+            return nil, fmt.Errorf("invalid value for argument '%s': expected true or false", def.Name)
+        }
+        return boolValue, nil
+    case "float64", "float32":
+        bitSize := 64
+        if def.DataType == "float32" {
+            bitSize = 32
+        }
+        if rawValue == "" {
+            return nil, fmt.Errorf("invalid value for argument '%s': expected a floating-point number, got empty", def.Name)
+        }
+        floatValue, err := strconv.ParseFloat(rawValue, bitSize)
+        if err != nil {
+            return nil, fmt.Errorf("invalid value for argument '%s': expected a floating-point number", def.Name)
+        }
+        if bitSize == 32 {
+            return float32(floatValue), nil
+        }
+        return floatValue, nil
+    case "duration":
+        if rawValue == "" {
+            return nil, fmt.Errorf("invalid value for argument '%s': expected a duration, got empty", def.Name)
+        }
+        duration, err := time.ParseDuration(rawValue)
+        if err != nil {
+            return nil, fmt.Errorf("invalid value for argument '%s': expected a duration (e.g. 300ms, 1.5h)", def.Name)
+        }
+        return duration, nil
+    case "string":
+        return rawValue, nil
+    case "url":
+        urlValue, err := url.Parse(rawValue)
+        if err != nil || urlValue.Scheme == "" || urlValue.Host == "" {
+            return nil, fmt.Errorf("invalid value for %s: expected a valid URL", flag)
+        }
+        return urlValue, nil
+    case "ip":
+        ipValue := net.ParseIP(rawValue)
+        if ipValue == nil {
+            return nil, fmt.Errorf("invalid value for %s: expected an IP address", flag)
+        }
+        return ipValue, nil
+    case "cidr":
+        _, network, err := net.ParseCIDR(rawValue)
+        if err != nil {
+            return nil, fmt.Errorf("invalid value for %s: expected a CIDR network (e.g. 10.0.0.0/8)", flag)
+        }
+        return network, nil
+    }
+    return nil, fmt.Errorf("unknown data type '%s' for argument '%s'", def.DataType, def.Name)
+}
 
+// convertSlice converts the raw values of a slice argument to its element type
+func convertSlice(def *Argument, rawValues []string) (interface{}, error) {
+    if def.DataType != "[]int" {
+        return rawValues, nil
+    }
+
+    values := []int{}
+    for _, rawValue := range rawValues {
+        intValue, err := parseInt(rawValue)
+        if err != nil {
+            return nil, fmt.Errorf("invalid value '%s' for argument '%s': expected an integer", rawValue, def.Name)
+        }
+        values = append(values, intValue)
+    }
+    return values, nil
+}
+
+// KeyValue is one key=value pair given to a map argument
+type KeyValue struct {
+    Key     string
+    Value   string
+}
+
+// convertPairs splits key=value tokens on the first "="
+func convertPairs(def *Argument, rawValues []string) ([]KeyValue, error) {
+    pairs := []KeyValue{}
+    for _, rawValue := range rawValues {
+        key, value, ok := strings.Cut(rawValue, "=")
+        if !ok || key == "" {
+            return nil, fmt.Errorf("invalid value '%s' for argument '%s': expected key=value", rawValue, def.Name)
+        }
+        pairs = append(pairs, KeyValue{Key: key, Value: value})
+    }
+    return pairs, nil
+}
+
+// isValueToken reports whether token can be consumed as a value for def.
+// Tokens starting with "-" are flags, except that numeric arguments (int,
+// float64, float32 and []int) accept negative numbers such as -5 or -1.5;
+// strings and []string never do.
+func isValueToken(def *Argument, token string) bool {
+    if !strings.HasPrefix(token, "-") {
+        return true
+    }
+    return isNumericType(def.DataType) && looksLikeNumber(token)
+}
+
+func isNumericType(dataType string) bool {
+    switch dataType {
+    case "int", "[]int", "float64", "float32":
+        return true
+    }
+    return false
+}
+
+func looksLikeNumber(token string) bool {
+    if _, err := parseInt(token); err == nil {
+        return true
+    }
+    _, err := strconv.ParseFloat(token, 64)
+    return err == nil
+}
+
+// applyDefaults fills in every argument that was not otherwise set, calling
+// DefaultFunc for arguments that have one.
+func applyDefaults(defs []*Argument, parsedArgs map[string]interface{}) error {
+    for _, def := range defs {
+        if def.linkTo != "" {
+            continue
+        }
+        if _, ok := parsedArgs[def.Name]; !ok {
+            if def.defaultFunc != nil {
+                value, err := def.defaultFunc()
+                if err != nil {
+                    return fmt.Errorf("could not compute default for argument '%s': %v", def.Name, err)
+                }
+                parsedArgs[def.Name] = value
+            } else if def.DefaultValue != nil {
+                parsedArgs[def.Name] = def.DefaultValue
+            } else if def.DataType == "bool" {
+                parsedArgs[def.Name] = false
+            } else if def.DataType == "count" {
+                parsedArgs[def.Name] = 0
+            }
+        }
+    }
+    return nil
+}
+
+// applyImplied sets the values implied by each passed argument, leaving
+// anything the user set explicitly untouched.
+func (p *Parser) applyImplied(parsedArgs map[string]interface{}) {
 	for _, arg := range p.args {
-		fmt.Printf("    -%s, --%s: %s\n", arg.Short, arg.Long, arg.Description)
+		if len(arg.implies) == 0 || !p.provided[arg.key()] || parsedArgs[arg.key()] == false {
+			continue
+		}
+		for name, value := range arg.implies {
+			target := p.lookupArgument(name)
+			if !p.provided[target.key()] {
+				parsedArgs[target.key()] = value
+			}
+		}
+	}
+}
+
+// nextCount returns a count argument bumped by one for this occurrence (e.g. -vvv => 3)
+func nextCount(parsedArgs map[string]interface{}, name string) int {
+    count, _ := parsedArgs[name].(int)
+    return count + 1
+}
+
+// setValue stores a parsed value and runs the argument's OnSet callback
+func setValue(def *Argument, parsedArgs map[string]interface{}, value interface{}) error {
+    parsedArgs[def.key()] = value
+    if def.onSet != nil {
+        return def.onSet(value)
+    }
+    return nil
+}
+
+// parseInt converts an integer value using Go literal syntax, accepting
+// 0x/0o/0b prefixes and underscore digit separators (e.g. 1_000_000).
+func parseInt(rawValue string) (int, error) {
+	value, err := strconv.ParseInt(rawValue, 0, strconv.IntSize)
+	return int(value), err
+}
+
+// Parse the CLI arguments
+func (p *Parser) Parse() (map[string]interface{}, bool, error) {
+	return p.ParseArgs(os.Args[1:])
+}
+
+// ParseArgs parses the given arguments, excluding the program name, without
+// touching os.Args. This is what Parse uses and is convenient in tests.
+func (p *Parser) ParseArgs(args []string) (map[string]interface{}, bool, error) {
+	result, err := p.parse(args)
+	return result.Values, result.ShouldExit, err
+}
+
+// ParseString parses a command line given as a single string, excluding the
+// program name, e.g. `--msg "hello world" --path /tmp\ dir`. Quotes and
+// backslash escapes are handled as in a POSIX shell; nothing is expanded.
+func (p *Parser) ParseString(command string) (map[string]interface{}, bool, error) {
+	args, err := splitShellWords(command)
+	if err != nil {
+		return nil, true, fmt.Errorf("could not split command line: %v", err)
 	}
+	return p.ParseArgs(args)
+}
+
+// MustParse parses the CLI arguments and exits on its own: with status 0 after
+// help or version output, and with status 1 after printing an error to the
+// error output. Otherwise it returns the parsed values.
+func (p *Parser) MustParse() map[string]interface{} {
+	parsedArgs, shouldExit, err := p.Parse()
+	if err != nil {
+		fmt.Fprintf(p.errOutput, "Error: %v\n", err)
+		p.exitFunc(1)
+		return nil
+	}
+	if shouldExit {
+		p.exitFunc(0)
+		return nil
+	}
+	return parsedArgs
+}
+
+// ParseV2 parses the CLI arguments into a ParseResult, which tells apart help,
+// version and error exits and offers typed accessors for the parsed values.
+func (p *Parser) ParseV2() (*ParseResult, error) {
+	return p.parse(os.Args[1:])
+}
+
+func (p *Parser) parse(args []string) (*ParseResult, error) {
+
+	// Reject parser definitions that can never be satisfied
+	if err := p.Validate(); err != nil {
+		return &ParseResult{ShouldExit: true}, err
+	}
+
+	// Handle "help" request or no arguments passed cases
+	if (len(args) == 0 && !p.allowEmptyArgs) {
+		if !p.quiet {
+			p.PrintHelp()
+		}
+		return &ParseResult{ShouldExit: true, HelpRequested: true}, nil
+	}
+
+	// Answer a completion script calling back for candidates
+	if len(args) > 0 && args[0] == CompleteKey {
+		for _, candidate := range p.Complete(args[1:]) {
+			fmt.Fprintln(p.output, candidate)
+		}
+		return &ParseResult{ShouldExit: true}, nil
+	}
+
+	// Split off a subcommand, which handles its own help and arguments. Each
+	// parser redacts its own secrets, so the command's arguments are traced
+	// separately.
+	args, cmd, cmdArgs := p.splitCommand(args)
+	if p.debugOutput() != nil {
+		p.trace("parsing %q", p.redactArgs(args))
+	}
+	if cmd != nil && p.debugOutput() != nil {
+		p.trace("command %s selected with %q", cmd.Name, cmd.redactArgs(cmdArgs))
+	}
+
+	if containsHelpArgument(args) {
+		if !p.quiet {
+			p.PrintHelp()
+		}
+		return &ParseResult{ShouldExit: true, HelpRequested: true}, nil
+	}
+
+	if requestedVersion(args) {
+		if !p.quiet {
+			p.PrintVersion()
+		}
+		return &ParseResult{ShouldExit: true, VersionRequested: true}, nil
+	}
+
+	// Parse the individual arguments based on p.args and command structure
+	parsedArgs := map[string]interface{}{}
+	p.positional = []string{}
+	p.remaining = []string{}
+	p.provided = map[string]bool{}
+	p.external = map[string]bool{}
+	p.warnings = []string{}
+	p.pairs = map[string][]KeyValue{}
+	p.raw = map[string][]string{}
+	p.errs = []error{}
+
+	// Parse global arguments using helper parseArguments func. Unknown
+	// arguments may have been collected in aggregate mode, but any other
+	// error leaves the command line half read, so it always stops here.
+	err := p.parseArguments(p.args, args, parsedArgs)
+	if err != nil {
+		p.errs = append(p.errs, err)
+		return &ParseResult{ShouldExit: true}, p.failure()
+	}
+	traced := map[string]bool{}
+	p.traceValues("command line", parsedArgs, traced)
+
+	// Environment-only arguments must not come from the command line
+	for _, arg := range p.args {
+		if arg.source == "env" && p.provided[arg.key()] {
+			err := fmt.Errorf("%s must be set via %s, not on the command line", p.displayName(arg.Name), p.envName(arg))
+			if p.fail(err) {
+				return &ParseResult{ShouldExit: true}, p.failure()
+			}
+		}
+	}
+
+	// Fill in values implied by the arguments that were passed
+	p.applyImplied(parsedArgs)
+	p.traceValues("implied", parsedArgs, traced)
+
+	// Layer values from the environment beneath the command line
+	if p.envPrefix != "" {
+		err = p.applyEnv(parsedArgs)
+		if err != nil && p.fail(err) {
+			return &ParseResult{ShouldExit: true}, p.failure()
+		}
+		p.traceValues("environment", parsedArgs, traced)
+	}
+
+	// Layer values from the designated config file beneath the command line
+	if p.autoConfig != "" {
+		err = p.applyAutoConfig(parsedArgs)
+		if err != nil && p.fail(err) {
+			return &ParseResult{ShouldExit: true}, p.failure()
+		}
+		p.traceValues("config file", parsedArgs, traced)
+	}
+
+	// Read a piped value for an argument that is still unset
+	err = p.applyStdin(parsedArgs)
+	if err != nil && p.fail(err) {
+		return &ParseResult{ShouldExit: true}, p.failure()
+	}
+	p.traceValues("stdin", parsedArgs, traced)
+
+	// Select the default member of exclusive groups left empty
+	groupDefaults := p.applyGroupDefaults(parsedArgs)
+	p.traceValues("group default", parsedArgs, traced)
+
+	// Check restricted values and patterns from every source before defaults fill in
+	for _, arg := range p.args {
+		if value, ok := parsedArgs[arg.key()]; ok {
+			if err := arg.checkChoice(value); err != nil && p.fail(err) {
+				return &ParseResult{ShouldExit: true}, p.failure()
+			}
+			if err := arg.checkPattern(p.displayName(arg.Name), value); err != nil && p.fail(err) {
+				return &ParseResult{ShouldExit: true}, p.failure()
+			}
+		}
+	}
+
+	// Validate global required args after parsing all subcommands. Defaults are
+	// not applied yet, so an explicitly provided empty value (--name "") or a
+	// config file value satisfies required while an absent bool does not.
+	// Every missing argument is reported at once to save the user round trips.
+	missing := []string{}
+	for _, arg := range p.args {
+		if arg.Required {
+			if _, ok := parsedArgs[arg.Name]; !ok && arg.DefaultValue == nil && arg.defaultFunc == nil {
+				missing = append(missing, arg.Name)
+			}
+		}
+	}
+	if len(missing) == 1 && p.fail(fmt.Errorf("missing required global argument: %s", missing[0])) {
+		return &ParseResult{ShouldExit: true}, p.failure()
+	}
+	if len(missing) > 1 {
+		sort.Strings(missing)
+		flags := []string{}
+		for _, name := range missing {
+			flags = append(flags, p.displayName(name))
+		}
+		if p.fail(fmt.Errorf("missing required arguments: %s", strings.Join(flags, ", "))) {
+			return &ParseResult{ShouldExit: true}, p.failure()
+		}
+	}
+
+	// Check requirements that depend on other arguments
+	err = p.validateConditionalRequired(parsedArgs)
+	if err != nil && p.fail(err) {
+		return &ParseResult{ShouldExit: true}, p.failure()
+	}
+
+	// Handle defaults after parsing
+	if !p.withoutDefaults {
+		err = applyDefaults(p.args, parsedArgs)
+		if err != nil && p.fail(err) {
+			return &ParseResult{ShouldExit: true}, p.failure()
+		}
+		p.traceValues("default", parsedArgs, traced)
+	}
+
+	// Validate mutual exclusivity
+	err = p.validateExclusiveGroups(parsedArgs)
+	if err != nil && p.fail(err) {
+		return &ParseResult{ShouldExit: true}, p.failure()
+	}
+
+	// Validate pairwise conflicts
+	err = p.validateConflicts()
+	if err != nil && p.fail(err) {
+		return &ParseResult{ShouldExit: true}, p.failure()
+	}
+
+	// Validate that require-any groups got at least one option
+	err = p.validateRequireAnyGroups()
+	if err != nil && p.fail(err) {
+		return &ParseResult{ShouldExit: true}, p.failure()
+	}
+
+	// Validate minimum counts of provided options
+	err = p.validateAtLeast()
+	if err != nil && p.fail(err) {
+		return &ParseResult{ShouldExit: true}, p.failure()
+	}
+
+	// Validate the number of positional arguments
+	err = p.validatePositionalRange()
+	if err != nil && p.fail(err) {
+		return &ParseResult{ShouldExit: true}, p.failure()
+	}
+
+	// Validate command-scoped global arguments
+	err = p.validateOnlyFor(cmd)
+	if err != nil && p.fail(err) {
+		return &ParseResult{ShouldExit: true}, p.failure()
+	}
+
+	if p.warningsAsErrors && len(p.warnings) > 0 {
+		p.fail(fmt.Errorf("%s", strings.Join(p.warnings, "; ")))
+	}
+
+	// Everything collected in aggregate mode is reported together
+	if len(p.errs) > 0 {
+		return &ParseResult{ShouldExit: true}, p.failure()
+	}
+
+	// Group defaults count for validation but are defaults all the same
+	if p.withoutDefaults {
+		for _, name := range groupDefaults {
+			delete(parsedArgs, name)
+		}
+	}
+
+	if cmd == nil {
+		p.applyBindings(parsedArgs)
+		return &ParseResult{Values: parsedArgs}, nil
+	}
+
+	// Parse the subcommand's arguments and merge them with the global ones
+	cmd.allowEmptyArgs = true
+	cmdResult, err := cmd.parse(cmdArgs)
+	if err != nil || cmdResult.ShouldExit {
+		return cmdResult, err
+	}
+	for name, value := range cmdResult.Values {
+		parsedArgs[name] = value
+	}
+	p.applyBindings(parsedArgs)
+	parsedArgs[CommandKey] = cmd.Name
+	return &ParseResult{Values: parsedArgs, Command: cmd.Name}, nil
+}
+
+// warn records a non-fatal problem found while parsing
+func (p *Parser) warn(format string, a ...interface{}) {
+	p.warnings = append(p.warnings, fmt.Sprintf(format, a...))
+}
+
+// Warnings returns the non-fatal problems found by the last Parse call, such
+// as a deprecated flag. Callers decide whether to print or log them.
+func (p *Parser) Warnings() []string {
+	return p.warnings
+}
+
+// SetPositionalRange enables positional arguments and requires between min and
+// max of them; a negative max means there is no upper bound.
+func (p *Parser) SetPositionalRange(min, max int) {
+	p.allowPositional = true
+	p.minPositional = min
+	p.maxPositional = max
+}
+
+func (p *Parser) validatePositionalRange() error {
+	count := len(p.positional)
+	switch {
+	case p.minPositional == p.maxPositional && count != p.minPositional:
+		return fmt.Errorf("expected exactly %d arguments, got %d", p.minPositional, count)
+	case count < p.minPositional:
+		return fmt.Errorf("expected at least %d arguments, got %d", p.minPositional, count)
+	case p.maxPositional >= 0 && count > p.maxPositional:
+		return fmt.Errorf("expected at most %d arguments, got %d", p.maxPositional, count)
+	}
+	return nil
+}
+
+// Positional returns the non-flag tokens collected by the last Parse call.
+// It is only populated when the parser was created WithAllowPositional.
+func (p *Parser) Positional() []string {
+	return p.positional
+}
+
+// Pairs returns the key=value pairs given to a map argument in the last Parse
+// call, in command line order and including repeated keys. The parsed map
+// holds the last value for each key.
+func (p *Parser) Pairs(name string) []KeyValue {
+	return p.pairs[name]
+}
+
+// NestedPairs expands the dotted keys of a map argument's pairs into nested
+// maps, helm --set style: --set a.b.c=1 --set a.d=2 gives
+// {"a": {"b": {"c": "1"}, "d": "2"}}. Later pairs win, and setting both a key
+// and a key below it (a=1 and a.b=2) is an error.
+func (p *Parser) NestedPairs(name string) (map[string]interface{}, error) {
+	nested := map[string]interface{}{}
+	for _, pair := range p.pairs[name] {
+		parts := strings.Split(pair.Key, ".")
+		node := nested
+		for i, part := range parts[:len(parts)-1] {
+			child, exists := node[part]
+			if !exists {
+				child = map[string]interface{}{}
+				node[part] = child
+			}
+			childMap, ok := child.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("cannot set %s: %s already has a value", pair.Key, strings.Join(parts[:i+1], "."))
+			}
+			node = childMap
+		}
+
+		last := parts[len(parts)-1]
+		if _, ok := node[last].(map[string]interface{}); ok {
+			return nil, fmt.Errorf("cannot set %s: it already has nested keys", pair.Key)
+		}
+		node[last] = pair.Value
+	}
+	return nested, nil
+}
+
+// RawValue returns the value of the named argument exactly as it was given in
+// the last Parse, before conversion or @file resolution, and whether it was
+// given at all. Slice and map tokens are joined with spaces; use RawValues to
+// get them separately. Flags without a value, like bools, give "".
+func (p *Parser) RawValue(name string) (string, bool) {
+	tokens, ok := p.raw[name]
+	return strings.Join(tokens, " "), ok
+}
+
+// RawValues returns the value tokens of the named argument as given in the
+// last Parse, e.g. ["a", "b"] for --tags a b.
+func (p *Parser) RawValues(name string) []string {
+	return p.raw[name]
+}
+
+// Provided reports whether the named argument was explicitly passed in the
+// last Parse call, as opposed to being filled in from its default.
+func (p *Parser) Provided(name string) bool {
+	return p.provided[name]
+}
+
+// Remaining returns the arguments left unparsed by the last Parse call. With
+// WithStopAtFirstUnknown it starts at the token that stopped parsing;
+// otherwise it holds the tokens after a "--" terminator when positional
+// arguments are not allowed.
+func (p *Parser) Remaining() []string {
+	return p.remaining
+}
+
+// EqualParsed reports whether two parsed argument maps hold the same values.
+// Slices are compared element by element, so a nil slice equals an empty one.
+func EqualParsed(a, b map[string]interface{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for name, aValue := range a {
+		bValue, ok := b[name]
+		if !ok || !equalValue(aValue, bValue) {
+			return false
+		}
+	}
+	return true
+}
+
+func equalValue(a, b interface{}) bool {
+	aValue, bValue := reflect.ValueOf(a), reflect.ValueOf(b)
+	if aValue.Kind() != reflect.Slice || bValue.Kind() != reflect.Slice {
+		return reflect.DeepEqual(a, b)
+	}
+
+	if aValue.Type() != bValue.Type() || aValue.Len() != bValue.Len() {
+		return false
+	}
+	for i := 0; i < aValue.Len(); i++ {
+		if !reflect.DeepEqual(aValue.Index(i).Interface(), bValue.Index(i).Interface()) {
+			return false
+		}
+	}
+	return true
+}
+
+// String summarizes the parser definition for debugging: program metadata
+// followed by each argument with its flags, type and whether it is required.
+func (p *Parser) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Parser{Name: %q, Version: %q}", p.Name, p.Version)
+	for _, arg := range p.args {
+		fmt.Fprintf(&b, "\n  %s [%s] %s", arg.Name, p.flagLabel(arg), arg.DataType)
+		if arg.Required {
+			b.WriteString(" required")
+		}
+		if arg.DefaultValue != nil {
+			fmt.Fprintf(&b, " default=%v", arg.DefaultValue)
+		}
+	}
+	return b.String()
 }
 
 // Helper function to check for help request
 func containsHelpArgument(args []string) bool {
 	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
 		if arg == "-h" || arg == "--help" {
 			return true
 		}
@@ -313,6 +1985,9 @@ func containsHelpArgument(args []string) bool {
 
 func requestedVersion(args []string) bool {
 	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
 		if arg == "--version" {
 			return true
 		}
@@ -0,0 +1,1153 @@
+package goparse
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// parseWith builds a quiet parser with setup applied and parses args
+func parseWith(t *testing.T, setup func(p *Parser), args ...string) (map[string]interface{}, error) {
+	t.Helper()
+	p := NewParser(WithQuiet(), WithAllowEmptyArgs())
+	setup(p)
+	parsed, _, err := p.ParseArgs(args)
+	return parsed, err
+}
+
+// wantError fails the test unless err contains want
+func wantError(t *testing.T, err error, want string) {
+	t.Helper()
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Fatalf("expected error containing %q, got %v", want, err)
+	}
+}
+
+func TestExclusiveGroup(t *testing.T) {
+	tests := []struct {
+		name		string
+		args		[]string
+		defaults	bool
+		mustHave	bool
+		wantErr		string
+	}{
+		{name: "neither provided", args: []string{}},
+		{name: "one provided", args: []string{"--json"}},
+		{name: "both provided", args: []string{"--json", "--yaml"}, wantErr: "only one of [json yaml] allowed"},
+		{name: "both defaulted", args: []string{"--other"}, defaults: true},
+		{name: "must have, neither provided", args: []string{}, mustHave: true, wantErr: "must be provided"},
+		{name: "must have, one provided", args: []string{"--yaml"}, mustHave: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseWith(t, func(p *Parser) {
+				if tt.defaults {
+					p.AddArgument("json", "", "json", "", "string", false, "a")
+					p.AddArgument("yaml", "", "yaml", "", "string", false, "b")
+				} else {
+					p.AddArgument("json", "", "json", "", "bool", false)
+					p.AddArgument("yaml", "", "yaml", "", "bool", false)
+				}
+				p.AddArgument("other", "", "other", "", "bool", false)
+				p.AddExclusiveGroup([]string{"json", "yaml"}, tt.mustHave)
+			}, tt.args...)
+
+			if tt.wantErr != "" {
+				wantError(t, err, tt.wantErr)
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// BenchmarkParseArgs parses thousands of tokens against 300 definitions, where
+// a linear scan per token would dominate
+func BenchmarkParseArgs(b *testing.B) {
+	p := NewParser(WithQuiet())
+	for i := 0; i < 300; i++ {
+		name := fmt.Sprintf("opt%d", i)
+		if i%3 == 0 {
+			p.AddArgument(name, "", name, "", "bool", false)
+		} else {
+			p.AddArgument(name, "", name, "", "string", false)
+		}
+	}
+
+	args := []string{}
+	for i := 0; i < 3000; i++ {
+		name := fmt.Sprintf("--opt%d", i%300)
+		if i%300%3 == 0 {
+			args = append(args, name)
+		} else {
+			args = append(args, name, "value")
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := p.ParseArgs(args); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestRepeatedFlagLastWins(t *testing.T) {
+	tests := []struct {
+		dataType	string
+		args		[]string
+		want		interface{}
+	}{
+		{dataType: "int", args: []string{"--retry", "1", "--retry", "2"}, want: 2},
+		{dataType: "float64", args: []string{"--retry", "1.5", "--retry", "2.5"}, want: 2.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dataType, func(t *testing.T) {
+			parsed, err := parseWith(t, func(p *Parser) {
+				p.AddArgument("retry", "r", "retry", "", tt.dataType, false)
+			}, tt.args...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if parsed["retry"] != tt.want {
+				t.Fatalf("retry = %v, want %v", parsed["retry"], tt.want)
+			}
+		})
+	}
+}
+
+func TestEmptyValueAfterEquals(t *testing.T) {
+	tests := []struct {
+		dataType	string
+		want		interface{}
+		wantErr		string
+	}{
+		{dataType: "string", want: ""},
+		{dataType: "int", wantErr: "expected an integer, got empty"},
+		{dataType: "float64", wantErr: "expected a floating-point number, got empty"},
+		{dataType: "float32", wantErr: "expected a floating-point number, got empty"},
+		{dataType: "duration", wantErr: "expected a duration, got empty"},
+		{dataType: "[]string", want: []string{}},
+		{dataType: "[]int", want: []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dataType, func(t *testing.T) {
+			p := NewParser(WithQuiet(), WithAllowPositional())
+			p.AddArgument("x", "", "x", "", tt.dataType, false)
+			parsed, _, err := p.ParseArgs([]string{"--x=", "input.txt"})
+			if tt.wantErr != "" {
+				wantError(t, err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !EqualParsed(map[string]interface{}{"x": parsed["x"]}, map[string]interface{}{"x": tt.want}) {
+				t.Fatalf("x = %#v, want %#v", parsed["x"], tt.want)
+			}
+			if !p.Provided("x") {
+				t.Fatal("expected x to be marked as provided")
+			}
+		})
+	}
+}
+
+func TestAttachedSliceValueIsWhole(t *testing.T) {
+	p := NewParser(WithQuiet(), WithAllowPositional())
+	p.AddArgument("files", "f", "files", "", "[]string", false)
+	parsed, _, err := p.ParseArgs([]string{"--files=a.txt", "input.txt"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !EqualParsed(map[string]interface{}{"files": parsed["files"]}, map[string]interface{}{"files": []string{"a.txt"}}) {
+		t.Fatalf("files = %v, want [a.txt]", parsed["files"])
+	}
+	if positional := p.Positional(); len(positional) != 1 || positional[0] != "input.txt" {
+		t.Fatalf("positional = %v, want [input.txt]", positional)
+	}
+}
+
+func TestEmptyStringValueSatisfiesRequired(t *testing.T) {
+	p := NewParser(WithQuiet())
+	p.AddArgument("name", "n", "name", "", "string", true)
+	parsed, _, err := p.ParseArgs([]string{"--name", ""})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if value, ok := parsed["name"]; !ok || value != "" {
+		t.Fatalf("name = %#v, want empty string", value)
+	}
+	if !p.Provided("name") {
+		t.Fatal("expected name to be marked as provided")
+	}
+}
+
+func TestClusterEndingInValuedShort(t *testing.T) {
+	parsed, err := parseWith(t, func(p *Parser) {
+		p.AddArgument("verbose", "v", "verbose", "", "bool", false)
+		p.AddArgument("file", "f", "file", "", "string", false)
+	}, "-vf", "out.txt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if parsed["verbose"] != true || parsed["file"] != "out.txt" {
+		t.Fatalf("got %v, want verbose=true file=out.txt", parsed)
+	}
+}
+
+func TestSliceConsumptionOfDashTokens(t *testing.T) {
+	setup := func(p *Parser) {
+		p.AddArgument("ports", "p", "ports", "", "[]int", false)
+		p.AddArgument("many", "m", "many", "", "[]string", false)
+		p.AddArgument("x", "x", "", "", "bool", false)
+	}
+
+	parsed, err := parseWith(t, setup, "--ports", "1", "-5")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !EqualParsed(map[string]interface{}{"ports": parsed["ports"]}, map[string]interface{}{"ports": []int{1, -5}}) {
+		t.Fatalf("ports = %v, want [1 -5]", parsed["ports"])
+	}
+
+	parsed, err = parseWith(t, setup, "--many", "a", "-x")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !EqualParsed(map[string]interface{}{"many": parsed["many"]}, map[string]interface{}{"many": []string{"a"}}) || parsed["x"] != true {
+		t.Fatalf("got %v, want many=[a] x=true", parsed)
+	}
+}
+
+func TestEnvFalseOverridesTrueDefault(t *testing.T) {
+	t.Setenv("MYPROG_COLOR", "false")
+	p := NewParser(WithQuiet(), WithAllowEmptyArgs(), WithEnvPrefix("MYPROG"))
+	p.AddArgument("color", "", "color", "", "bool", false, true)
+	parsed, _, err := p.ParseArgs([]string{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if parsed["color"] != false {
+		t.Fatalf("color = %v, want false", parsed["color"])
+	}
+}
+
+func TestPairsKeepCommandLineOrder(t *testing.T) {
+	p := NewParser(WithQuiet())
+	p.AddArgument("header", "H", "header", "", "map[string]string", false)
+	parsed, _, err := p.ParseArgs([]string{"-H", "b=2", "-H", "a=1", "-H", "b=3"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []KeyValue{{Key: "b", Value: "2"}, {Key: "a", Value: "1"}, {Key: "b", Value: "3"}}
+	pairs := p.Pairs("header")
+	if len(pairs) != len(want) {
+		t.Fatalf("Pairs = %v, want %v", pairs, want)
+	}
+	for i := range want {
+		if pairs[i] != want[i] {
+			t.Fatalf("Pairs = %v, want %v", pairs, want)
+		}
+	}
+
+	// The map keeps the last value for a repeated key
+	if header := parsed["header"].(map[string]string); header["b"] != "3" || header["a"] != "1" {
+		t.Fatalf("header = %v, want a=1 b=3", header)
+	}
+}
+
+func TestDefaultTrueBool(t *testing.T) {
+	setup := func(p *Parser) {
+		p.AddArgument("color", "", "color", "", "bool", false, true)
+	}
+
+	parsed, err := parseWith(t, setup)
+	if err != nil || parsed["color"] != true {
+		t.Fatalf("absent: color = %v, err = %v, want true", parsed["color"], err)
+	}
+
+	parsed, err = parseWith(t, setup, "--no-color")
+	if err != nil || parsed["color"] != false {
+		t.Fatalf("--no-color: color = %v, err = %v, want false", parsed["color"], err)
+	}
+}
+
+func TestOverlappingExclusiveGroups(t *testing.T) {
+	setup := func(p *Parser) {
+		for _, name := range []string{"a", "b", "c"} {
+			p.AddArgument(name, "", name, "", "bool", false)
+		}
+		p.AddExclusiveGroup([]string{"a", "b"}, false)
+		p.AddExclusiveGroup([]string{"b", "c"}, false)
+	}
+
+	for _, args := range [][]string{{"--b"}, {"--a", "--c"}} {
+		if _, err := parseWith(t, setup, args...); err != nil {
+			t.Fatalf("%v: unexpected error: %v", args, err)
+		}
+	}
+
+	_, err := parseWith(t, setup, "--a", "--b")
+	wantError(t, err, "only one of [a b] allowed")
+}
+
+func TestImplies(t *testing.T) {
+	setup := func(p *Parser) {
+		p.AddArgument("debug", "d", "debug", "", "bool", false).
+			Implies(map[string]interface{}{"verbose": true, "log-level": "debug"})
+		p.AddArgument("verbose", "v", "verbose", "", "bool", false)
+		p.AddArgument("log-level", "", "log-level", "", "string", false, "info")
+	}
+
+	parsed, err := parseWith(t, setup, "--debug")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if parsed["verbose"] != true || parsed["log-level"] != "debug" {
+		t.Fatalf("got %v, want verbose=true log-level=debug", parsed)
+	}
+
+	parsed, err = parseWith(t, setup, "--debug", "--log-level", "warn")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if parsed["log-level"] != "warn" {
+		t.Fatalf("log-level = %v, want the user's warn", parsed["log-level"])
+	}
+}
+
+func TestSliceStopsAtTerminator(t *testing.T) {
+	p := NewParser(WithQuiet(), WithAllowPositional())
+	p.AddArgument("files", "f", "files", "", "[]string", false)
+	parsed, _, err := p.ParseArgs([]string{"--files", "a", "b", "--", "rest"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !EqualParsed(map[string]interface{}{"files": parsed["files"]}, map[string]interface{}{"files": []string{"a", "b"}}) {
+		t.Fatalf("files = %v, want [a b]", parsed["files"])
+	}
+	if positional := p.Positional(); len(positional) != 1 || positional[0] != "rest" {
+		t.Fatalf("positional = %v, want [rest]", positional)
+	}
+}
+
+func TestAllMissingRequiredReported(t *testing.T) {
+	_, err := parseWith(t, func(p *Parser) {
+		p.AddArgument("input", "i", "input", "", "string", true)
+		p.AddArgument("config", "c", "config", "", "string", true)
+		p.AddArgument("verbose", "v", "verbose", "", "bool", false)
+	}, "--verbose")
+	wantError(t, err, "missing required arguments: --config, --input")
+}
+
+func TestBoolValueOnlyWithEquals(t *testing.T) {
+	tests := []struct {
+		args		[]string
+		want		bool
+		positional	[]string
+	}{
+		{args: []string{"--verbose"}, want: true},
+		{args: []string{"--verbose=false"}, want: false},
+		{args: []string{"--verbose=true"}, want: true},
+		{args: []string{"--verbose", "false"}, want: true, positional: []string{"false"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			p := NewParser(WithQuiet(), WithAllowPositional())
+			p.AddArgument("verbose", "v", "verbose", "", "bool", false)
+			parsed, _, err := p.ParseArgs(tt.args)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if parsed["verbose"] != tt.want {
+				t.Fatalf("verbose = %v, want %v", parsed["verbose"], tt.want)
+			}
+			if strings.Join(p.Positional(), " ") != strings.Join(tt.positional, " ") {
+				t.Fatalf("positional = %v, want %v", p.Positional(), tt.positional)
+			}
+		})
+	}
+
+	_, err := parseWith(t, func(p *Parser) {
+		p.AddArgument("verbose", "v", "verbose", "", "bool", false)
+	}, "--verbose=maybe")
+	wantError(t, err, "expected true or false")
+}
+
+func TestAttachedShortNumber(t *testing.T) {
+	for _, args := range [][]string{{"-n5"}, {"-n", "5"}, {"-n=5"}} {
+		parsed, err := parseWith(t, func(p *Parser) {
+			p.AddArgument("lines", "n", "lines", "", "int", false)
+		}, args...)
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", args, err)
+		}
+		if parsed["lines"] != 5 {
+			t.Fatalf("%v: lines = %#v, want 5", args, parsed["lines"])
+		}
+	}
+}
+
+func TestEqualsValueThatLooksLikeFlag(t *testing.T) {
+	parsed, err := parseWith(t, func(p *Parser) {
+		p.AddArgument("message", "m", "message", "", "string", false)
+		p.AddArgument("verbose", "v", "verbose", "", "bool", false)
+	}, "--message=--verbose")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if parsed["message"] != "--verbose" || parsed["verbose"] != false {
+		t.Fatalf("got %v, want message=--verbose verbose=false", parsed)
+	}
+}
+
+func TestMalformedClusters(t *testing.T) {
+	for _, token := range []string{"-v--", "-v-", "-v.x", "-v!"} {
+		_, err := parseWith(t, func(p *Parser) {
+			p.AddArgument("verbose", "v", "verbose", "", "bool", false)
+			p.AddArgument("x", "x", "", "", "bool", false)
+		}, token)
+		wantError(t, err, "malformed flag cluster: "+token)
+	}
+}
+
+func TestValuedFlagPositionInCluster(t *testing.T) {
+	setup := func(p *Parser) {
+		p.AddArgument("a", "a", "", "", "string", false)
+		p.AddArgument("b", "b", "", "", "bool", false)
+	}
+
+	_, err := parseWith(t, setup, "-ab")
+	wantError(t, err, "-a takes a value and cannot appear before other flags in a cluster")
+
+	parsed, err := parseWith(t, setup, "-ba", "value")
+	if err != nil || parsed["a"] != "value" || parsed["b"] != true {
+		t.Fatalf("-ba value: got %v, err = %v", parsed, err)
+	}
+
+	parsed, err = parseWith(t, setup, "-abvalue")
+	if err != nil || parsed["a"] != "bvalue" || parsed["b"] != false {
+		t.Fatalf("-abvalue: got %v, err = %v", parsed, err)
+	}
+}
+
+func TestGroupDefaultSatisfiesDependency(t *testing.T) {
+	parsed, err := parseWith(t, func(p *Parser) {
+		p.AddArgument("json", "", "json", "", "bool", false)
+		p.AddArgument("yaml", "", "yaml", "", "bool", false)
+		p.AddExclusiveGroup([]string{"json", "yaml"}, true).Default = "json"
+		p.AddRequireAnyGroup([]string{"json", "yaml"})
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if parsed["json"] != true {
+		t.Fatalf("json = %v, want true", parsed["json"])
+	}
+}
+
+func TestExclusiveErrorListsProvidedMembers(t *testing.T) {
+	_, err := parseWith(t, func(p *Parser) {
+		for _, name := range []string{"output", "log", "tee"} {
+			p.AddArgument(name, "", name, "", "bool", false)
+		}
+		p.AddExclusiveGroup([]string{"output", "log", "tee"}, false)
+	}, "--tee", "--output", "--log")
+	wantError(t, err, "only one of [output log tee] allowed, but output, log, tee were all provided")
+}
+
+func TestParseDebugRedactsSecrets(t *testing.T) {
+	var trace strings.Builder
+	p := NewParser(WithQuiet(), WithParseDebug(&trace))
+	p.AddArgument("password", "p", "password", "", "string", false).Secret()
+	p.AddArgument("user", "u", "user", "", "string", false)
+	cmd := p.AddCommand("login", "")
+	cmd.AddArgument("token", "t", "token", "", "string", false).Secret()
+
+	_, _, err := p.ParseArgs([]string{"--password", "hunter2", "-phunter3", "--password=hunter4", "-u", "me", "login", "-t", "hunter5"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(trace.String(), "hunter") {
+		t.Fatalf("trace leaks a secret:\n%s", trace.String())
+	}
+	if !strings.Contains(trace.String(), `"me"`) {
+		t.Fatalf("trace lost a non-secret value:\n%s", trace.String())
+	}
+}
+
+func TestCommandAfterShortCluster(t *testing.T) {
+	setup := func(p *Parser) {
+		p.AddArgument("verbose", "v", "verbose", "", "bool", false)
+		p.AddArgument("output", "o", "output", "", "string", false)
+		p.AddCommand("commit", "")
+	}
+
+	// -vo takes "commit" as the output, so no command is selected
+	parsed, err := parseWith(t, setup, "-vo", "commit")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if parsed["output"] != "commit" || parsed[CommandKey] != nil {
+		t.Fatalf("got %v, want output=commit and no command", parsed)
+	}
+
+	parsed, err = parseWith(t, setup, "-vo", "out.txt", "commit")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if parsed["output"] != "out.txt" || parsed[CommandKey] != "commit" {
+		t.Fatalf("got %v, want output=out.txt command=commit", parsed)
+	}
+
+	parsed, err = parseWith(t, setup, "-voout.txt", "commit")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if parsed["output"] != "out.txt" || parsed[CommandKey] != "commit" {
+		t.Fatalf("got %v, want output=out.txt command=commit", parsed)
+	}
+}
+
+func TestCommandArgumentNameCollision(t *testing.T) {
+	_, err := parseWith(t, func(p *Parser) {
+		p.AddArgument("name", "n", "name", "", "string", false)
+		p.AddCommand("run", "").AddArgument("name", "", "name", "", "string", false, "sub")
+	}, "--name", "X", "run")
+	wantError(t, err, "argument 'name' of command 'run' has the same name as a global argument")
+}
+
+func TestMergeKeepsConflicts(t *testing.T) {
+	_, err := parseWith(t, func(p *Parser) {
+		shared := NewParser()
+		shared.AddArgument("fast", "", "fast", "", "bool", false)
+		shared.AddArgument("thorough", "", "thorough", "", "bool", false)
+		shared.AddConflict("fast", "thorough")
+		if err := p.Merge(shared); err != nil {
+			t.Fatal(err)
+		}
+	}, "--fast", "--thorough")
+	wantError(t, err, "--fast conflicts with --thorough")
+}
+
+func TestMergeKeepsAtLeastRules(t *testing.T) {
+	_, err := parseWith(t, func(p *Parser) {
+		shared := NewParser()
+		for _, name := range []string{"a", "b", "c"} {
+			shared.AddArgument(name, "", name, "", "bool", false)
+		}
+		shared.RequireAtLeast(2, "a", "b", "c")
+		if err := p.Merge(shared); err != nil {
+			t.Fatal(err)
+		}
+	}, "--a")
+	wantError(t, err, "at least 2 of [a b c] must be provided, got 1")
+}
+
+func TestMergeKeepsBindings(t *testing.T) {
+	var name string
+	_, err := parseWith(t, func(p *Parser) {
+		shared := NewParser()
+		shared.StringVar(&name, "name", "", "name", "def", "")
+		if err := p.Merge(shared); err != nil {
+			t.Fatal(err)
+		}
+	}, "--name", "zz")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if name != "zz" {
+		t.Fatalf("name = %q, want zz", name)
+	}
+}
+
+func TestAutoConfigPathFromEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"level": 7}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("APP_CONFIG", path)
+
+	p := NewParser(WithQuiet(), WithAllowEmptyArgs(), WithEnvPrefix("APP"))
+	p.AddArgument("config", "c", "config", "", "string", false)
+	p.AddArgument("level", "l", "level", "", "int", false, 1)
+	p.AutoConfig("config")
+	parsed, _, err := p.ParseArgs([]string{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if parsed["level"] != 7 {
+		t.Fatalf("level = %v, want 7 from the config file", parsed["level"])
+	}
+}
+
+func TestAutoConfigValidation(t *testing.T) {
+	_, err := parseWith(t, func(p *Parser) {
+		p.AddArgument("config", "c", "config", "", "string", false)
+		p.AutoConfig("typo")
+	})
+	wantError(t, err, "AutoConfig names 'typo', which is not a string argument")
+}
+
+func TestAutoConfigEmptyYAMLKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("name:\nlevel: 3\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := parseWith(t, func(p *Parser) {
+		p.AddArgument("config", "c", "config", "", "string", false)
+		p.AddArgument("name", "n", "name", "", "string", false, "default")
+		p.AddArgument("level", "l", "level", "", "int", false)
+		p.AutoConfig("config")
+	}, "--config", path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if parsed["name"] != "default" || parsed["level"] != 3 {
+		t.Fatalf("got %v, want name=default level=3", parsed)
+	}
+}
+
+func TestAutoConfigYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	contents := "# settings\nname: prod # the target\ntags:\n  - a\n  - b\nlabels:\n  team: core\n"
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := parseWith(t, func(p *Parser) {
+		p.AddArgument("config", "c", "config", "", "string", false)
+		p.AddArgument("name", "n", "name", "", "string", false)
+		p.AddArgument("tags", "t", "tags", "", "[]string", false)
+		p.AddArgument("labels", "l", "labels", "", "map[string]string", false)
+		p.AutoConfig("config")
+	}, "--config", path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"config":	path,
+		"name":		"prod",
+		"tags":		[]string{"a", "b"},
+		"labels":	map[string]string{"team": "core"},
+	}
+	if !EqualParsed(parsed, want) {
+		t.Fatalf("got %v, want %v", parsed, want)
+	}
+}
+
+func TestRepeatedFlagsDoNotWarn(t *testing.T) {
+	p := NewParser(WithQuiet(), WithWarningsAsErrors())
+	p.AddArgument("include", "i", "include", "", "string", false)
+	p.AddArgument("exclude", "e", "exclude", "", "string", false)
+	p.AddArgument("verbose", "v", "", "", "count", false).LinkTo("verbosity")
+	p.AddArgument("verbosity", "", "verbosity", "", "int", false)
+
+	for _, args := range [][]string{{"--include", "X", "--exclude", "Y", "--include", "Z"}, {"-vv", "--verbosity", "5"}} {
+		if _, _, err := p.ParseArgs(args); err != nil {
+			t.Fatalf("%v: unexpected error: %v", args, err)
+		}
+		if len(p.Warnings()) > 0 {
+			t.Fatalf("%v: unexpected warnings %v", args, p.Warnings())
+		}
+	}
+}
+
+func TestWithoutDefaultsKeepsGroupDefaultValid(t *testing.T) {
+	p := NewParser(WithQuiet(), WithAllowEmptyArgs(), WithoutDefaults())
+	p.AddArgument("json", "", "json", "", "bool", false)
+	p.AddArgument("yaml", "", "yaml", "", "bool", false)
+	p.AddExclusiveGroup([]string{"json", "yaml"}, true).Default = "json"
+	parsed, _, err := p.ParseArgs([]string{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(parsed) != 0 {
+		t.Fatalf("got %v, want an empty map", parsed)
+	}
+}
+
+func TestHelpListsConflictPairs(t *testing.T) {
+	p := NewParser(WithQuiet())
+	speed := p.NewArgumentGroup("Speed")
+	speed.AddArgument("fast", "", "fast", "Skip checks", "bool", false)
+	speed.AddArgument("thorough", "", "thorough", "Run every check", "bool", false)
+	p.AddArgument("verbose", "v", "verbose", "More output", "bool", false)
+	p.AddConflict("fast", "thorough")
+
+	var out strings.Builder
+	p.PrintHelpTo(&out)
+	help := out.String()
+	if !strings.Contains(help, "Speed (choose at most one):") {
+		t.Fatalf("help has no conflict block:\n%s", help)
+	}
+	if strings.Count(help, "--fast:") != 1 {
+		t.Fatalf("--fast listed more than once:\n%s", help)
+	}
+}
+
+func TestConditionalRequiredContextualError(t *testing.T) {
+	tests := []struct {
+		name	string
+		setup	func(p *Parser)
+		args	[]string
+	}{
+		{name: "required unless", setup: func(p *Parser) {
+			p.AddArgument("input", "", "input", "File to read", "string", false).RequiredUnless("stdin")
+			p.AddArgument("stdin", "", "stdin", "Read standard input", "bool", false)
+		}, args: []string{}},
+		{name: "required if", setup: func(p *Parser) {
+			p.AddArgument("input", "", "input", "File to read", "string", false).RequiredIf("stdin")
+			p.AddArgument("stdin", "", "stdin", "Read standard input", "bool", false)
+		}, args: []string{"--stdin"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser(WithQuiet(), WithAllowEmptyArgs(), WithContextualErrors())
+			tt.setup(p)
+			_, _, err := p.ParseArgs(tt.args)
+			wantError(t, err, "--input: File to read")
+			wantError(t, err, "--stdin: Read standard input")
+		})
+	}
+}
+
+func TestReconstructCommand(t *testing.T) {
+	p := NewParser(WithQuiet())
+	p.AddArgument("verbose", "v", "verbose", "", "bool", false)
+	commit := p.AddCommand("commit", "Record changes")
+	commit.AddArgument("message", "m", "message", "", "string", false)
+
+	args := []string{"-v", "commit", "-m", "hi"}
+	parsed, _, err := p.ParseArgs(args)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := p.Reconstruct(parsed)
+	want := []string{"--verbose", "commit", "--message", "hi"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	reparsed, _, err := p.ParseArgs(got)
+	if err != nil {
+		t.Fatalf("reconstructed args do not parse: %v", err)
+	}
+	if fmt.Sprint(reparsed) != fmt.Sprint(parsed) {
+		t.Fatalf("round trip got %v, want %v", reparsed, parsed)
+	}
+}
+
+func TestShortMustBeASCII(t *testing.T) {
+	tests := []struct {
+		name	string
+		short	string
+		wantErr	string
+	}{
+		{name: "ascii", short: "v"},
+		{name: "non-ascii", short: "é", wantErr: "must be a single ASCII character"},
+		{name: "two characters", short: "vv", wantErr: "must be a single ASCII character"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseWith(t, func(p *Parser) {
+				p.AddArgument("verbose", tt.short, "verbose", "", "bool", false)
+			}, "-"+tt.short)
+			if tt.wantErr != "" {
+				wantError(t, err, tt.wantErr)
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestNumberUnderscores(t *testing.T) {
+	tests := []struct {
+		dataType	string
+		value		string
+		want		interface{}
+		wantErr		string
+	}{
+		{dataType: "int", value: "1_000_000", want: 1000000},
+		{dataType: "int", value: "0x_ff", want: 255},
+		{dataType: "int", value: "1__0", wantErr: "invalid value"},
+		{dataType: "int", value: "_1", wantErr: "invalid value"},
+		{dataType: "int", value: "1_", wantErr: "invalid value"},
+		{dataType: "float64", value: "1_000.5", want: 1000.5},
+		{dataType: "float64", value: "1__0", wantErr: "invalid value"},
+		{dataType: "float64", value: "_1", wantErr: "invalid value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dataType+" "+tt.value, func(t *testing.T) {
+			parsed, err := parseWith(t, func(p *Parser) {
+				p.AddArgument("n", "", "n", "", tt.dataType, false)
+			}, "--n="+tt.value)
+			if tt.wantErr != "" {
+				wantError(t, err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if parsed["n"] != tt.want {
+				t.Fatalf("got %v, want %v", parsed["n"], tt.want)
+			}
+		})
+	}
+}
+
+func TestSchemaDurationIsPlainString(t *testing.T) {
+	p := NewParser(WithQuiet())
+	p.AddArgument("timeout", "", "timeout", "", "duration", false)
+	schema, err := p.ExportSchema()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(string(schema), `"format"`) {
+		t.Fatalf("duration has a format in the schema: %s", schema)
+	}
+}
+
+func TestYAMLDocument(t *testing.T) {
+	tests := []struct {
+		name	string
+		yaml	string
+		want	string
+	}{
+		{
+			name:	"sequence at key indent followed by a key",
+			yaml:	"arguments:\n- name: x\n- name: y\nexclusive_groups: []\n",
+			want:	"map[arguments:[map[name:x] map[name:y]] exclusive_groups:[]]",
+		},
+		{
+			name:	"nested mappings in a sequence",
+			yaml:	"items:\n  - name: a\n    env:\n      HOME: /root\n      USER: root\n  - name: b\nlast: 1\n",
+			want:	"map[items:[map[env:map[HOME:/root USER:root] name:a] map[name:b]] last:1]",
+		},
+		{
+			name:	"quoted scalars stay strings",
+			yaml:	"a: \"007\"\nb: 'true'\nc: \"x # y\"\nd: \"z\" # comment\ne: \"a: b\"\n",
+			want:	"map[a:007 b:true c:x # y d:z e:a: b]",
+		},
+		{
+			name:	"plain scalars are typed",
+			yaml:	"a: 007\nb: true\nc: ~\nd: 1.5\ne: text # comment\n",
+			want:	"map[a:7 b:true c:<nil> d:1.5 e:text]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseYAMLDocument([]byte(tt.yaml))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if fmt.Sprint(got) != tt.want {
+				t.Fatalf("got %v, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// Quoted strings must keep their type, not just their printed form
+	got, _ := parseYAMLDocument([]byte("a: \"007\"\nb: 'true'\n"))
+	values := got.(map[string]interface{})
+	if values["a"] != "007" || values["b"] != "true" {
+		t.Fatalf("quoted scalars were retyped: %#v", values)
+	}
+}
+
+func TestSpecSequenceAtKeyIndent(t *testing.T) {
+	spec := `name: tool
+arguments:
+- name: json
+  long: json
+  type: bool
+- name: yaml
+  long: yaml
+  type: bool
+exclusive_groups:
+- options: [json, yaml]
+  title: "Output: format"
+`
+	p, err := NewParserFromSpec(strings.NewReader(spec))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(p.args) != 2 || len(p.exclusiveGroups) != 1 || p.exclusiveGroups[0].Title != "Output: format" {
+		t.Fatalf("spec not read in full: %d arguments, groups %v", len(p.args), p.exclusiveGroups)
+	}
+}
+
+func TestAggregateKeepsSourceErrors(t *testing.T) {
+	t.Setenv("APP_TOKEN", "secret")
+	t.Setenv("APP_RETRIES", "many")
+
+	tests := []struct {
+		name	string
+		setup	func(p *Parser)
+		wantErr	string
+	}{
+		{name: "command line only", setup: func(p *Parser) {
+			p.AddArgument("token", "", "token", "", "string", false).SourceCLIOnly()
+		}, wantErr: "only on the command line"},
+		{name: "bad environment value", setup: func(p *Parser) {
+			p.AddArgument("retries", "", "retries", "", "int", false)
+		}, wantErr: "retries"},
+		{name: "bad default", setup: func(p *Parser) {
+			p.AddArgument("level", "", "level", "", "int", false).DefaultFunc(func() (interface{}, error) {
+				return nil, fmt.Errorf("no level configured")
+			})
+		}, wantErr: "no level configured"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser(WithQuiet(), WithAllowEmptyArgs(), WithErrorMode("aggregate"), WithEnvPrefix("APP"))
+			tt.setup(p)
+			_, _, err := p.ParseArgs([]string{"--bogus"})
+			wantError(t, err, "bogus")
+			wantError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestEnvAndConfigCountForGroups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"yaml": true}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name	string
+		env		map[string]string
+		args	[]string
+		rule	func(p *Parser)
+		wantErr	string
+	}{
+		{name: "env satisfies must-have group", env: map[string]string{"APP_JSON": "true"}, rule: func(p *Parser) {
+			p.AddExclusiveGroup([]string{"json", "yaml"}, true)
+		}},
+		{name: "config satisfies must-have group", args: []string{"--config", path}, rule: func(p *Parser) {
+			p.AddExclusiveGroup([]string{"json", "yaml"}, true)
+		}},
+		{name: "env satisfies require-any", env: map[string]string{"APP_JSON": "true"}, rule: func(p *Parser) {
+			p.AddRequireAnyGroup([]string{"json", "yaml"})
+		}},
+		{name: "env and config satisfy at-least", env: map[string]string{"APP_JSON": "true"}, args: []string{"--config", path}, rule: func(p *Parser) {
+			p.RequireAtLeast(2, "json", "yaml")
+		}},
+		{name: "env and command line both count", env: map[string]string{"APP_JSON": "true"}, args: []string{"--yaml"}, rule: func(p *Parser) {
+			p.AddConflict("json", "yaml")
+		}, wantErr: "--json conflicts with --yaml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			p := NewParser(WithQuiet(), WithAllowEmptyArgs(), WithEnvPrefix("APP"))
+			p.AddArgument("config", "", "config", "", "string", false)
+			p.AddArgument("json", "", "json", "", "bool", false)
+			p.AddArgument("yaml", "", "yaml", "", "bool", false)
+			p.AutoConfig("config")
+			tt.rule(p)
+			_, _, err := p.ParseArgs(tt.args)
+			if tt.wantErr != "" {
+				wantError(t, err, tt.wantErr)
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestConfigLargeNumbers(t *testing.T) {
+	tests := []struct {
+		file		string
+		contents	string
+	}{
+		{file: "config.json", contents: `{"retries": 1000000, "id": 12345678901234567890, "ratio": 0.000001}`},
+		{file: "config.yaml", contents: "retries: 1000000\nid: 12345678901234567890\nratio: 0.000001\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.contents), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			parsed, err := parseWith(t, func(p *Parser) {
+				p.AddArgument("config", "", "config", "", "string", false)
+				p.AddArgument("retries", "", "retries", "", "int", false)
+				p.AddArgument("id", "", "id", "", "string", false)
+				p.AddArgument("ratio", "", "ratio", "", "float64", false)
+				p.AutoConfig("config")
+			}, "--config", path)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if parsed["retries"] != 1000000 || parsed["id"] != "12345678901234567890" || parsed["ratio"] != 0.000001 {
+				t.Fatalf("got retries=%v id=%v ratio=%v", parsed["retries"], parsed["id"], parsed["ratio"])
+			}
+		})
+	}
+}
+
+func TestDefaultConfigRoundTrip(t *testing.T) {
+	defaults := map[string]interface{}{
+		"zip":		"007",
+		"exp":		"1e3",
+		"flag":		"true",
+		"null":		"null",
+		"tilde":	"~",
+		"list":		"[a, b]",
+		"dash":		"- x",
+		"hash":		"a #b",
+		"plain":	"text",
+		"tags":		[]string{"007", "true", "-x"},
+		"level":	5,
+		"ratio":	0.5,
+		"labels":	map[string]string{"id": "007"},
+	}
+	define := func(p *Parser, withDefaults bool) {
+		for name, value := range defaults {
+			dataType := "string"
+			switch value.(type) {
+			case []string:
+				dataType = "[]string"
+			case int:
+				dataType = "int"
+			case float64:
+				dataType = "float64"
+			case map[string]string:
+				dataType = "map[string]string"
+			}
+			if withDefaults {
+				p.AddArgument(name, "", name, "", dataType, false, value)
+			} else {
+				p.AddArgument(name, "", name, "", dataType, false)
+			}
+		}
+	}
+
+	for _, format := range []string{"yaml", "json"} {
+		t.Run(format, func(t *testing.T) {
+			writer := NewParser(WithQuiet())
+			define(writer, true)
+			var out strings.Builder
+			if err := writer.WriteDefaultConfig(&out, format); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			path := filepath.Join(t.TempDir(), "config."+format)
+			if err := os.WriteFile(path, []byte(out.String()), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			parsed, err := parseWith(t, func(p *Parser) {
+				p.AddArgument("config", "", "config", "", "string", false)
+				p.AutoConfig("config")
+				define(p, false)
+			}, "--config", path)
+			if err != nil {
+				t.Fatalf("unexpected error: %v\n%s", err, out.String())
+			}
+			for name, want := range defaults {
+				if fmt.Sprintf("%#v", parsed[name]) != fmt.Sprintf("%#v", want) {
+					t.Errorf("%s = %#v, want %#v\n%s", name, parsed[name], want, out.String())
+				}
+			}
+		})
+	}
+}
+
+func TestEnvOnlyArgumentInHelp(t *testing.T) {
+	p := NewParser(WithQuiet(), WithEnvPrefix("APP"))
+	p.AddArgument("token", "", "", "API token", "string", false).SourceEnvOnly()
+	p.AddArgument("verbose", "v", "verbose", "More output", "bool", false)
+
+	if usage := p.Usage(); strings.Contains(usage, "STRING") {
+		t.Fatalf("usage lists the env-only argument: %s", usage)
+	}
+
+	for _, style := range []string{"", "compact"} {
+		p.helpStyle = style
+		var out strings.Builder
+		p.PrintHelpTo(&out)
+		if !strings.Contains(out.String(), "APP_TOKEN") || strings.Contains(out.String(), "    : ") {
+			t.Fatalf("%q help does not label the env-only argument:\n%s", style, out.String())
+		}
+	}
+
+	for _, problem := range p.Lint() {
+		if strings.Contains(problem, "token") {
+			t.Fatalf("lint reports the env-only argument: %s", problem)
+		}
+	}
+}
+
+func TestReconstructValuesThatLookLikeFlags(t *testing.T) {
+	tests := []struct {
+		name	string
+		args	[]string
+		want	[]string
+	}{
+		{name: "slice element", args: []string{"--tags", "a", "--tags=-b"}, want: []string{"--tags=a", "--tags=-b"}},
+		{name: "plain slice", args: []string{"--tags", "a", "b"}, want: []string{"--tags", "a", "b"}},
+		{name: "short-only scalar", args: []string{"-n=-x"}, want: []string{"-n=-x"}},
+		{name: "map key", args: []string{"--set=-k=v"}, want: []string{"--set=-k=v"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser(WithQuiet(), WithAllowEmptyArgs())
+			p.AddArgument("tags", "t", "tags", "", "[]string", false)
+			p.AddArgument("n", "n", "", "", "string", false)
+			p.AddArgument("set", "", "set", "", "map[string]string", false)
+
+			parsed, _, err := p.ParseArgs(tt.args)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := p.Reconstruct(parsed)
+			if fmt.Sprintf("%q", got) != fmt.Sprintf("%q", tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+
+			reparsed, _, err := p.ParseArgs(got)
+			if err != nil {
+				t.Fatalf("reconstructed args do not parse: %v", err)
+			}
+			if !EqualParsed(reparsed, parsed) {
+				t.Fatalf("round trip got %v, want %v", reparsed, parsed)
+			}
+		})
+	}
+}
+
+func TestRepeatedSliceFlagsAccumulate(t *testing.T) {
+	parsed, err := parseWith(t, func(p *Parser) {
+		p.AddArgument("tags", "t", "tags", "", "[]string", false)
+		p.AddArgument("ports", "p", "ports", "", "[]int", false)
+	}, "--tags", "a", "-t=-b", "--ports", "1", "--ports", "2", "3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]interface{}{"tags": []string{"a", "-b"}, "ports": []int{1, 2, 3}}
+	if !EqualParsed(parsed, want) {
+		t.Fatalf("got %v, want %v", parsed, want)
+	}
+}
@@ -0,0 +1,33 @@
+package goparse
+
+// ArgumentOption applies a shared setting to an argument
+type ArgumentOption func(*Argument)
+
+// ArgumentGroup adds arguments that share a help section and common settings
+type ArgumentGroup struct {
+	Title	string
+	parser	*Parser
+	shared	[]ArgumentOption
+}
+
+// NewArgumentGroup creates a group whose arguments are listed together under
+// title in help. Each shared option is applied to every argument added to it.
+func (p *Parser) NewArgumentGroup(title string, shared ...ArgumentOption) *ArgumentGroup {
+	g := &ArgumentGroup{
+		Title:	title,
+		parser:	p,
+		shared:	shared,
+	}
+	p.groups = append(p.groups, g)
+	return g
+}
+
+// AddArgument adds an argument to the parser as a member of the group
+func (g *ArgumentGroup) AddArgument(name, short, long, description string, dataType string, required bool, defaultValue ...interface{}) *Argument {
+	arg := g.parser.AddArgument(name, short, long, description, dataType, required, defaultValue...)
+	arg.group = g.Title
+	for _, option := range g.shared {
+		option(arg)
+	}
+	return arg
+}
@@ -0,0 +1,519 @@
+package goparse
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// PrintVersion does the obvious
+func(p *Parser) PrintVersion() {
+	p.writeVersion(p.output)
+}
+
+// VersionString returns the version output as a string
+func (p *Parser) VersionString() string {
+	var out bytes.Buffer
+	p.writeVersion(&out)
+	return out.String()
+}
+
+// Help returns the help message as a string, e.g. for embedding in custom
+// error output or tests.
+func (p *Parser) Help() string {
+	var out bytes.Buffer
+	p.PrintHelpTo(&out)
+	return out.String()
+}
+
+func (p *Parser) writeVersion(w io.Writer) {
+	if p.versionTemplate != "" && p.writeVersionTemplate(w) == nil {
+		return
+	}
+
+	if p.Version != "" {
+		fmt.Fprintf(w, "%s %s: %s\n", p.Name, p.translate("Version"), p.Version)
+	} else {
+		fmt.Fprintln(w, p.translate("No version information provided by program."))
+	}
+}
+
+// writeVersionTemplate renders the WithVersionTemplate template to w
+func (p *Parser) writeVersionTemplate(w io.Writer) error {
+	tmpl, err := template.New("version").Parse(p.versionTemplate)
+	if err != nil {
+		return err
+	}
+
+	data := map[string]string{
+		"Name":		p.Name,
+		"Version":	p.Version,
+		"Author":	p.Author,
+	}
+	for key, value := range p.versionFields {
+		data[key] = value
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return err
+	}
+	fmt.Fprintln(w, strings.TrimRight(out.String(), "\n"))
+	return nil
+}
+
+// PrintHelp does the obvious, writing to the parser's output (stdout by default)
+func (p *Parser) PrintHelp() {
+	p.PrintHelpTo(p.output)
+}
+
+// PrintErrorHelp writes help to the parser's error output (stderr by default),
+// for showing usage after a parse error rather than on an explicit --help.
+func (p *Parser) PrintErrorHelp() {
+	p.PrintHelpTo(p.errOutput)
+}
+
+// PrintUsage writes only the one-line usage synopsis to the parser's error
+// output, for terse error reporting where the full help would be noise.
+func (p *Parser) PrintUsage() {
+	fmt.Fprintln(p.errOutput, p.Usage())
+}
+
+// InvocationName returns the command users type to run the program: the
+// WithInvocationName value, or else the base name of os.Args[0]. Subcommands
+// are prefixed with their parent's invocation, e.g. "git commit".
+func (p *Parser) InvocationName() string {
+	if p.parent != nil {
+		return p.parent.InvocationName() + " " + p.Name
+	}
+	if p.invocationName != "" {
+		return p.invocationName
+	}
+	return filepath.Base(os.Args[0])
+}
+
+// Usage returns the usage synopsis, e.g. "Usage: prog --input STRING [--verbose]".
+// Required arguments are listed bare and optional ones in brackets.
+func (p *Parser) Usage() string {
+	parts := []string{p.translate("Usage") + ":", p.InvocationName()}
+
+	for _, arg := range p.orderedArgs() {
+		// Environment-only arguments have nothing to type
+		if arg.replaced || (arg.Short == "" && arg.Long == "") {
+			continue
+		}
+		part := usageFlag(arg)
+		if !arg.Required {
+			part = "[" + part + "]"
+		}
+		parts = append(parts, part)
+	}
+
+	if len(p.commands) > 0 {
+		parts = append(parts, "<command>")
+	}
+	if p.allowPositional {
+		parts = append(parts, "[args...]")
+	}
+	return strings.Join(parts, " ")
+}
+
+// usageFlag renders one argument for the usage synopsis, preferring the long form
+func usageFlag(arg *Argument) string {
+	flag := "-" + arg.Short
+	if arg.Long != "" {
+		flag = "--" + arg.Long
+	}
+
+	if arg.DataType == "bool" || arg.DataType == "count" {
+		return flag
+	}
+	if strings.HasPrefix(arg.DataType, "[]") {
+		return flag + " " + placeholder(arg) + "..."
+	}
+	return flag + " " + placeholder(arg)
+}
+
+// placeholder returns the name shown for an argument's value: its Metavar, or
+// else a name derived from the data type.
+func placeholder(arg *Argument) string {
+	switch {
+	case arg.metavar != "":
+		return arg.metavar
+	case arg.DataType == "map[string]string":
+		return "KEY=VALUE"
+	}
+	return strings.ToUpper(strings.TrimPrefix(arg.DataType, "[]"))
+}
+
+// PrintHelpTo writes the help message to w
+func (p *Parser) PrintHelpTo(w io.Writer) {
+	// Optional program metadata
+	if p.Name != "" {
+		fmt.Fprintf(w, "%s\n", p.Name)
+	}
+	if p.Author != "" {
+		fmt.Fprintf(w, "%s: %s\n", p.translate("Author"), p.Author)
+	}
+	if p.Version != "" {
+		fmt.Fprintf(w, "%s: %s\n", p.translate("Version"), p.Version)
+	}
+	if p.Description != "" {
+		fmt.Fprintf(w, "%s\n", p.Description)
+	}
+
+	// Name is a display title; the synopsis shows the command to type
+	fmt.Fprintln(w, p.Usage())
+
+	args := p.orderedArgs()
+	blocks, claimed := p.exclusiveBlocks(args)
+
+	// Renamed flags keep working but are no longer advertised
+	ungrouped := []*Argument{}
+	for _, arg := range args {
+		if arg.group == "" && !arg.replaced && !claimed[arg.Name] {
+			ungrouped = append(ungrouped, arg)
+		}
+	}
+	p.writeArgumentList(w, ungrouped)
+
+	// Members of exclusive groups are listed together, so the constraint shows
+	for _, block := range blocks {
+		fmt.Fprintf(w, "\n%s:\n", block.heading)
+		p.writeArgumentList(w, block.members)
+	}
+
+	// Argument groups follow in the order they were created
+	for _, group := range p.groups {
+		members := []*Argument{}
+		for _, arg := range args {
+			if arg.group == group.Title && !arg.replaced && !claimed[arg.Name] {
+				members = append(members, arg)
+			}
+		}
+		if len(members) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s:\n", p.translate(group.Title))
+		p.writeArgumentList(w, members)
+	}
+
+	if len(p.commands) > 0 {
+		fmt.Fprintf(w, "\n%s:\n", p.translate("Commands"))
+		width := 0
+		for _, cmd := range p.commands {
+			if len(cmd.Name) > width {
+				width = len(cmd.Name)
+			}
+		}
+		for _, cmd := range p.commands {
+			fmt.Fprintf(w, "    %-*s  %s\n", width, cmd.Name, cmd.Description)
+		}
+	}
+
+	if len(p.examples) > 0 {
+		fmt.Fprintf(w, "\n%s:\n", p.translate("Examples"))
+		for _, example := range p.examples {
+			fmt.Fprintf(w, "    %s\n", example[0])
+			if example[1] != "" {
+				fmt.Fprintf(w, "        %s\n", example[1])
+			}
+		}
+	}
+}
+
+// helpBlock is a labeled set of arguments listed together in help
+type helpBlock struct {
+	heading	string
+	members	[]*Argument
+}
+
+// exclusiveBlocks returns a help block per exclusive group and per conflict
+// pair, headed e.g. "Output mode (choose one):", and the names of the
+// arguments they list. The label is the group's Title, or else the title of
+// the argument group all its members belong to. An argument in several blocks
+// is listed in the first one only.
+func (p *Parser) exclusiveBlocks(args []*Argument) ([]helpBlock, map[string]bool) {
+	// Conflict pairs are exclusive groups where neither flag is needed
+	groups := append([]*ExclusiveGroup{}, p.exclusiveGroups...)
+	for _, conflict := range p.conflicts {
+		groups = append(groups, &ExclusiveGroup{Options: conflict[:]})
+	}
+
+	blocks := []helpBlock{}
+	claimed := map[string]bool{}
+	for _, group := range groups {
+		members := []*Argument{}
+		titles := map[string]bool{}
+		for _, arg := range args {
+			if slices.Contains(group.Options, arg.Name) && !arg.replaced && !claimed[arg.Name] {
+				members = append(members, arg)
+				titles[arg.group] = true
+			}
+		}
+		if len(members) < 2 {
+			continue
+		}
+
+		label := group.Title
+		if label == "" && len(titles) == 1 && members[0].group != "" {
+			label = members[0].group
+		}
+		heading := p.translate("Choose at most one")
+		hint := p.translate("choose at most one")
+		if group.MustHave {
+			heading, hint = p.translate("Choose one"), p.translate("choose one")
+		}
+		if label != "" {
+			heading = fmt.Sprintf("%s (%s)", p.translate(label), hint)
+		}
+
+		for _, arg := range members {
+			claimed[arg.Name] = true
+		}
+		blocks = append(blocks, helpBlock{heading: heading, members: members})
+	}
+	return blocks, claimed
+}
+
+// orderedArgs returns the arguments in the order set by WithHelpOrder:
+// alphabetical by name (the default), as declared, or required ones first.
+func (p *Parser) orderedArgs() []*Argument {
+	args := append([]*Argument{}, p.args...)
+	switch p.helpOrder {
+	case "declared":
+	case "required-first":
+		sort.SliceStable(args, func(i, j int) bool {
+			if args[i].Required != args[j].Required {
+				return args[i].Required
+			}
+			return args[i].Name < args[j].Name
+		})
+	default:
+		sort.SliceStable(args, func(i, j int) bool {
+			return args[i].Name < args[j].Name
+		})
+	}
+	return args
+}
+
+// writeArgumentList writes one help line per argument in the configured help style
+func (p *Parser) writeArgumentList(w io.Writer, args []*Argument) {
+	switch p.helpStyle {
+	case "compact":
+		for _, arg := range args {
+			fmt.Fprintf(w, "    %s\n", p.compactHelpLine(arg))
+			if arg.example != "" {
+				fmt.Fprintf(w, "        %s: %s\n", p.translate("Example"), arg.example)
+			}
+		}
+	default:
+		// Piped output gets plain lines instead of a padded table
+		if !p.isTerminal(w) {
+			for _, arg := range args {
+				fmt.Fprintf(w, "    %s: %s\n", p.flagLabel(arg), p.helpDescription(arg))
+				if arg.example != "" {
+					fmt.Fprintf(w, "        %s: %s\n", p.translate("Example"), arg.example)
+				}
+			}
+			return
+		}
+
+		width := 0
+		for _, arg := range args {
+			if len(p.flagLabel(arg)) > width {
+				width = len(p.flagLabel(arg))
+			}
+		}
+		for _, arg := range args {
+			fmt.Fprintf(w, "    %-*s  %s\n", width, p.flagLabel(arg), p.helpDescription(arg))
+			if arg.example != "" {
+				fmt.Fprintf(w, "    %-*s  %s: %s\n", width, "", p.translate("Example"), arg.example)
+			}
+		}
+	}
+}
+
+// isTerminal reports whether w is an interactive terminal, unless overridden
+// with WithForceTTY.
+func (p *Parser) isTerminal(w io.Writer) bool {
+	if p.ttyOverride {
+		return p.forceTTY
+	}
+
+	file, ok := w.(*os.File)
+	return ok && isCharDevice(file)
+}
+
+// isCharDevice reports whether file is a terminal or other character device
+func isCharDevice(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// contextualError appends the help lines of the named arguments to err when
+// the parser was created WithContextualErrors.
+func (p *Parser) contextualError(err error, names []string) error {
+	if !p.contextualErrors {
+		return err
+	}
+
+	args := []*Argument{}
+	for _, name := range names {
+		if arg := p.lookupArgument(name); arg != nil {
+			args = append(args, arg)
+		}
+	}
+
+	var usage bytes.Buffer
+	p.writeArgumentList(&usage, args)
+	return fmt.Errorf("%w\n%s", err, strings.TrimRight(usage.String(), "\n"))
+}
+
+// pointAt appends a diagnostic to err that repeats the command line with a
+// caret under args[index], when the error output is a terminal:
+//
+//	myprog --verbose --config
+//	                 ^^^^^^^^ expected a value
+//
+// Piped output keeps the plain one-line error.
+func (p *Parser) pointAt(err error, args []string, index int, note string) error {
+	if !p.isTerminal(p.errOutput) {
+		return err
+	}
+
+	line, marker := p.InvocationName(), ""
+	for i, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t'\"") {
+			arg = strconv.Quote(arg)
+		}
+		if i == index {
+			marker = strings.Repeat(" ", len(line)+1) + strings.Repeat("^", len(arg))
+		}
+		line += " " + arg
+	}
+	return fmt.Errorf("%w\n    %s\n    %s %s", err, line, marker, note)
+}
+
+// flagLabel renders the short and long forms of an argument, e.g. "-c, --config",
+// or the environment variable of one that has neither
+func (p *Parser) flagLabel(arg *Argument) string {
+	forms := []string{}
+	if arg.Short != "" {
+		forms = append(forms, "-"+arg.Short)
+	}
+	if arg.Long != "" {
+		forms = append(forms, "--"+arg.Long)
+	}
+	if len(forms) == 0 {
+		return p.envName(arg)
+	}
+	if arg.metavar != "" {
+		return strings.Join(forms, ", ") + " " + arg.metavar
+	}
+	return strings.Join(forms, ", ")
+}
+
+// compactHelpLine renders an argument as "--long (-s) TYPE  description"
+func (p *Parser) compactHelpLine(arg *Argument) string {
+	line := ""
+	switch {
+	case arg.Long != "" && arg.Short != "":
+		line = fmt.Sprintf("--%s (-%s)", arg.Long, arg.Short)
+	case arg.Long != "":
+		line = "--" + arg.Long
+	case arg.Short != "":
+		line = "-" + arg.Short
+	default:
+		line = p.envName(arg)
+	}
+	if arg.DataType != "bool" {
+		line += " " + placeholder(arg)
+	}
+	return line + "  " + p.helpDescription(arg)
+}
+
+// helpDescription returns the argument description with hints derived from
+// its type, e.g. that a count flag can be repeated.
+func (p *Parser) helpDescription(arg *Argument) string {
+	description := arg.Description
+	if p.translator != nil {
+		key := "description:" + arg.Name
+		if translated := p.translator(key); translated != "" && translated != key {
+			description = translated
+		}
+	}
+
+	hint := ""
+	switch {
+	case len(arg.choices) > 0:
+		hint = fmt.Sprintf("(%s: %s)", p.translate("one of"), strings.Join(arg.choices, ", "))
+	case arg.DataType == "count" && arg.Short != "":
+		hint = fmt.Sprintf("(%s, %s -%s)", p.translate("repeatable"), p.translate("e.g."), strings.Repeat(arg.Short, 3))
+	case arg.DataType == "count":
+		hint = fmt.Sprintf("(%s)", p.translate("repeatable"))
+	case arg.DataType == "bool" && arg.DefaultValue == true && arg.Long != "":
+		hint = fmt.Sprintf("(%s: true, %s --no-%s)", p.translate("default"), p.translate("disable with"), arg.Long)
+	case arg.DataType == "map[string]string":
+		hint = fmt.Sprintf("(key=value, %s)", p.translate("repeatable"))
+	case strings.HasPrefix(arg.DataType, "[]"):
+		hint = fmt.Sprintf("(%s)", p.translate("accepts multiple values"))
+	}
+
+	// Bools and counts have their defaults covered above
+	if arg.DataType != "bool" && arg.DataType != "count" {
+		if value := p.formatDefault(arg.DefaultValue); value != "" {
+			hint = strings.TrimSpace(hint + fmt.Sprintf(" (%s: %s)", p.translate("default"), value))
+		}
+	}
+
+	if hint == "" {
+		return description
+	}
+	if description == "" {
+		return hint
+	}
+	return description + " " + hint
+}
+
+// formatDefault renders a default value for help. Slices are comma-joined
+// unless the parser was created WithSliceDefaultFormat.
+func (p *Parser) formatDefault(value interface{}) string {
+	items := []string{}
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []string:
+		items = v
+	case []int:
+		for _, item := range v {
+			items = append(items, strconv.Itoa(item))
+		}
+	default:
+		return fmt.Sprint(v)
+	}
+
+	if len(items) == 0 {
+		return ""
+	}
+	if p.sliceDefaultFormat != nil {
+		return p.sliceDefaultFormat(items)
+	}
+	return strings.Join(items, ", ")
+}
+
+// translate returns the translation of a static help label, or the label
+// itself when no translator is configured.
+func (p *Parser) translate(key string) string {
+	if p.translator == nil {
+		return key
+	}
+	return p.translator(key)
+}
@@ -0,0 +1,77 @@
+package goparse
+
+import (
+	"fmt"
+)
+
+// knownDataTypes lists the data types parseArguments can convert
+var knownDataTypes = map[string]bool{
+	"string":				true,
+	"int":					true,
+	"float64":				true,
+	"float32":				true,
+	"duration":				true,
+	"bool":					true,
+	"count":				true,
+	"url":					true,
+	"ip":					true,
+	"cidr":					true,
+	"[]string":				true,
+	"[]int":				true,
+	"map[string]string":	true,
+}
+
+// Lint reports likely mistakes in the parser definition, such as arguments
+// without a description or duplicate short flags. Unlike Validate it does not
+// stop at the first problem; it returns one message per problem found, which
+// makes it handy to call from a program's own tests.
+func (p *Parser) Lint() []string {
+	problems := []string{}
+	shorts := map[string]string{}
+	longs := map[string]string{}
+
+	for _, arg := range p.args {
+		if arg.Description == "" {
+			problems = append(problems, fmt.Sprintf("argument '%s' has no description", arg.Name))
+		}
+		if arg.Required && arg.DefaultValue != nil {
+			problems = append(problems, fmt.Sprintf("argument '%s' is required but has a default value", arg.Name))
+		}
+		if !knownDataTypes[arg.DataType] {
+			problems = append(problems, fmt.Sprintf("argument '%s' has unknown data type '%s'", arg.Name, arg.DataType))
+		}
+		if arg.Short == "" && arg.Long == "" && arg.source != "env" {
+			problems = append(problems, fmt.Sprintf("argument '%s' has neither a short nor a long flag", arg.Name))
+		}
+		if arg.Short != "" && !isASCIIShort(arg.Short) {
+			problems = append(problems, fmt.Sprintf("argument '%s' has a short flag that is not a single ASCII character: -%s", arg.Name, arg.Short))
+		}
+
+		if other, ok := shorts[arg.Short]; ok && arg.Short != "" {
+			problems = append(problems, fmt.Sprintf("arguments '%s' and '%s' share the short flag -%s", other, arg.Name, arg.Short))
+		} else if arg.Short != "" {
+			shorts[arg.Short] = arg.Name
+		}
+		if other, ok := longs[arg.Long]; ok && arg.Long != "" {
+			problems = append(problems, fmt.Sprintf("arguments '%s' and '%s' share the long flag --%s", other, arg.Name, arg.Long))
+		} else if arg.Long != "" {
+			longs[arg.Long] = arg.Name
+		}
+	}
+
+	for _, group := range p.exclusiveGroups {
+		for _, name := range group.Options {
+			if p.lookupArgument(name) == nil {
+				problems = append(problems, fmt.Sprintf("exclusive group %v references unknown argument '%s'", group.Options, name))
+			}
+		}
+	}
+	for _, group := range p.requireAnyGroups {
+		for _, name := range group {
+			if p.lookupArgument(name) == nil {
+				problems = append(problems, fmt.Sprintf("require-any group %v references unknown argument '%s'", group, name))
+			}
+		}
+	}
+	return problems
+}
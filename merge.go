@@ -0,0 +1,43 @@
+package goparse
+
+import "fmt"
+
+// Merge copies other's arguments and groups into p, so reusable flag sets
+// (logging flags, TLS flags, ...) can be defined once and combined. It fails
+// without modifying p if any name, short or long flag collides.
+func (p *Parser) Merge(other *Parser) error {
+	for _, arg := range other.args {
+		for _, existing := range p.args {
+			switch {
+			case arg.Name == existing.Name:
+				return fmt.Errorf("cannot merge argument '%s': name already defined", arg.Name)
+			case arg.Short != "" && arg.Short == existing.Short:
+				return fmt.Errorf("cannot merge argument '%s': short flag -%s already used by '%s'", arg.Name, arg.Short, existing.Name)
+			case arg.Long != "" && arg.Long == existing.Long:
+				return fmt.Errorf("cannot merge argument '%s': long flag --%s already used by '%s'", arg.Name, arg.Long, existing.Name)
+			}
+		}
+	}
+
+	p.args = append(p.args, other.args...)
+	p.exclusiveGroups = append(p.exclusiveGroups, other.exclusiveGroups...)
+	p.requireAnyGroups = append(p.requireAnyGroups, other.requireAnyGroups...)
+	p.conflicts = append(p.conflicts, other.conflicts...)
+	p.atLeast = append(p.atLeast, other.atLeast...)
+	p.bindings = append(p.bindings, other.bindings...)
+	for _, group := range other.groups {
+		if !p.hasGroup(group.Title) {
+			p.groups = append(p.groups, group)
+		}
+	}
+	return nil
+}
+
+func (p *Parser) hasGroup(title string) bool {
+	for _, group := range p.groups {
+		if group.Title == title {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package goparse
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// redacted replaces the value of Secret arguments in Reconstruct output
+const redacted = "REDACTED"
+
+// Secret marks the argument's value as sensitive, so Reconstruct writes
+// REDACTED in its place, e.g. for logging the effective command line.
+func (a *Argument) Secret() *Argument {
+	a.secret = true
+	return a
+}
+
+// Reconstruct turns parsed values back into a canonical argument slice, e.g.
+// ["--config", "foo", "--verbose", "--tags", "a", "b"], for logging the
+// effective command or building a wrapper invocation. Arguments are written
+// in name order using their long flag where they have one, and values equal
+// to their defaults are left out. Values of Secret arguments are redacted. A
+// selected subcommand follows the global arguments with its own.
+func (p *Parser) Reconstruct(parsed map[string]interface{}) []string {
+	args := []*Argument{}
+	for _, arg := range p.args {
+		// Linked and renamed flags share their target's value
+		if arg.linkTo == "" && (arg.Short != "" || arg.Long != "") {
+			args = append(args, arg)
+		}
+	}
+	sort.Slice(args, func(i, j int) bool {
+		return args[i].Name < args[j].Name
+	})
+
+	tokens := []string{}
+	for _, arg := range args {
+		value, ok := parsed[arg.Name]
+		if !ok || value == nil || equalValue(value, arg.defaultForReconstruct()) {
+			continue
+		}
+		tokens = append(tokens, arg.reconstruct(value)...)
+	}
+
+	// The subcommand's values share the map with the global ones
+	if name, ok := parsed[CommandKey].(string); ok {
+		if cmd := p.lookupCommand(name); cmd != nil {
+			tokens = append(tokens, name)
+			tokens = append(tokens, cmd.Reconstruct(parsed)...)
+		}
+	}
+	return tokens
+}
+
+// defaultForReconstruct returns the value the argument has when not passed
+func (a *Argument) defaultForReconstruct() interface{} {
+	switch {
+	case a.DefaultValue != nil:
+		return a.DefaultValue
+	case a.DataType == "bool":
+		return false
+	case a.DataType == "count":
+		return 0
+	}
+	return nil
+}
+
+// reconstruct renders one argument and its value as command line tokens
+func (a *Argument) reconstruct(value interface{}) []string {
+	flag := "-" + a.Short
+	if a.Long != "" {
+		flag = "--" + a.Long
+	}
+
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return []string{flag}
+		}
+		if a.Long != "" {
+			return []string{"--no-" + a.Long}
+		}
+		return nil
+	}
+
+	if a.DataType == "count" {
+		count, _ := value.(int)
+		if a.Short != "" {
+			return []string{"-" + strings.Repeat(a.Short, count)}
+		}
+		tokens := []string{}
+		for i := 0; i < count; i++ {
+			tokens = append(tokens, flag)
+		}
+		return tokens
+	}
+
+	if a.secret {
+		return []string{flag, redacted}
+	}
+
+	// A value that looks like a flag has to be attached to stay a value
+	switch v := value.(type) {
+	case []string:
+		if len(v) == 0 {
+			return []string{flag + "="}
+		}
+		if !slices.ContainsFunc(v, func(item string) bool { return strings.HasPrefix(item, "-") }) {
+			return append([]string{flag}, v...)
+		}
+		// Repeated flags add up, so each element can be attached on its own
+		tokens := []string{}
+		for _, item := range v {
+			tokens = append(tokens, flag+"="+item)
+		}
+		return tokens
+	case []int:
+		tokens := []string{flag}
+		for _, item := range v {
+			tokens = append(tokens, fmt.Sprint(item))
+		}
+		return tokens
+	case map[string]string:
+		keys := []string{}
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		tokens := []string{}
+		for _, key := range keys {
+			if strings.HasPrefix(key, "-") {
+				tokens = append(tokens, flag+"="+key+"="+v[key])
+			} else {
+				tokens = append(tokens, flag, key+"="+v[key])
+			}
+		}
+		return tokens
+	}
+
+	text := fmt.Sprint(value)
+	if strings.HasPrefix(text, "-") {
+		return []string{flag + "=" + text}
+	}
+	return []string{flag, text}
+}
@@ -0,0 +1,54 @@
+package goparse
+
+// ParseResult holds the outcome of ParseV2
+type ParseResult struct {
+	Values				map[string]interface{}	// Parsed values keyed by argument name
+	ShouldExit			bool					// True when help or version was printed, or parsing failed
+	HelpRequested		bool					// True when help was printed
+	VersionRequested	bool					// True when version information was printed
+	Command				string					// Name of the selected subcommand, if any
+}
+
+// Get returns the parsed value for name and whether it is present
+func (r *ParseResult) Get(name string) (interface{}, bool) {
+	value, ok := r.Values[name]
+	return value, ok
+}
+
+// GetOrDefault returns the named value, or fallback if it is absent or nil
+func (r *ParseResult) GetOrDefault(name string, fallback interface{}) interface{} {
+	if value, ok := r.Values[name]; ok && value != nil {
+		return value
+	}
+	return fallback
+}
+
+// GetString returns the named value as a string, or "" if absent or not a string
+func (r *ParseResult) GetString(name string) string {
+	value, _ := r.Values[name].(string)
+	return value
+}
+
+// GetInt returns the named value as an int, or 0 if absent or not an int
+func (r *ParseResult) GetInt(name string) int {
+	value, _ := r.Values[name].(int)
+	return value
+}
+
+// GetBool returns the named value as a bool, or false if absent or not a bool
+func (r *ParseResult) GetBool(name string) bool {
+	value, _ := r.Values[name].(bool)
+	return value
+}
+
+// GetStringSlice returns the named value as a []string, or nil if absent or not a []string
+func (r *ParseResult) GetStringSlice(name string) []string {
+	value, _ := r.Values[name].([]string)
+	return value
+}
+
+// GetIntSlice returns the named value as an []int, or nil if absent or not an []int
+func (r *ParseResult) GetIntSlice(name string) []int {
+	value, _ := r.Values[name].([]int)
+	return value
+}
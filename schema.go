@@ -0,0 +1,86 @@
+package goparse
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ExportSchema describes the parser's arguments as a JSON Schema object, with
+// one property per argument name carrying its type, description and default.
+func (p *Parser) ExportSchema() ([]byte, error) {
+	properties := map[string]interface{}{}
+	required := []string{}
+
+	for _, arg := range p.args {
+		property := jsonSchemaType(arg.DataType)
+		if arg.Description != "" {
+			property["description"] = arg.Description
+		}
+		if arg.DefaultValue != nil {
+			property["default"] = jsonDefault(arg.DefaultValue)
+		}
+		if len(arg.choices) > 0 {
+			if items, ok := property["items"].(map[string]interface{}); ok {
+				items["enum"] = arg.choices
+			} else {
+				property["enum"] = arg.choices
+			}
+		}
+		properties[arg.Name] = property
+
+		if arg.Required {
+			required = append(required, arg.Name)
+		}
+	}
+
+	schema := map[string]interface{}{
+		"$schema":		"https://json-schema.org/draft/2020-12/schema",
+		"type":			"object",
+		"properties":	properties,
+	}
+	if p.Name != "" {
+		schema["title"] = p.Name
+	}
+	if p.Description != "" {
+		schema["description"] = p.Description
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+
+	out, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("could not export schema: %v", err)
+	}
+	return out, nil
+}
+
+// jsonSchemaType maps an argument data type to its JSON Schema type keywords
+func jsonSchemaType(dataType string) map[string]interface{} {
+	if strings.HasPrefix(dataType, "[]") {
+		return map[string]interface{}{
+			"type":		"array",
+			"items":	jsonSchemaType(strings.TrimPrefix(dataType, "[]")),
+		}
+	}
+
+	switch dataType {
+	case "map[string]string":
+		return map[string]interface{}{
+			"type":					"object",
+			"additionalProperties":	map[string]interface{}{"type": "string"},
+		}
+	case "bool":
+		return map[string]interface{}{"type": "boolean"}
+	case "int", "count":
+		return map[string]interface{}{"type": "integer"}
+	case "float64", "float32":
+		return map[string]interface{}{"type": "number"}
+	case "url":
+		return map[string]interface{}{"type": "string", "format": "uri"}
+	}
+	// Durations use Go syntax such as 1h30m, not the ISO 8601 that JSON
+	// Schema's "duration" format means, so they stay plain strings
+	return map[string]interface{}{"type": "string"}
+}
@@ -0,0 +1,75 @@
+package goparse
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// splitShellWords splits a command line into arguments the way a POSIX shell
+// would, without expansion: whitespace separates words, single quotes keep
+// everything literally, double quotes allow \" and \\ escapes, and a
+// backslash outside quotes escapes the next character (e.g. /tmp\ dir).
+func splitShellWords(line string) ([]string, error) {
+	words := []string{}
+	var word strings.Builder
+	inWord := false
+	runes := []rune(line)
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case r == '\\':
+			if i+1 >= len(runes) {
+				return nil, fmt.Errorf("unterminated escape at end of input")
+			}
+			i++
+			word.WriteRune(runes[i])
+			inWord = true
+		case r == '\'':
+			closed := false
+			for i++; i < len(runes); i++ {
+				if runes[i] == '\'' {
+					closed = true
+					break
+				}
+				word.WriteRune(runes[i])
+			}
+			if !closed {
+				return nil, fmt.Errorf("unterminated single quote")
+			}
+			inWord = true
+		case r == '"':
+			closed := false
+			for i++; i < len(runes); i++ {
+				if runes[i] == '"' {
+					closed = true
+					break
+				}
+				// Inside double quotes a backslash only escapes " \ $ and `
+				if runes[i] == '\\' && i+1 < len(runes) && strings.ContainsRune("\"\\$`", runes[i+1]) {
+					i++
+				}
+				word.WriteRune(runes[i])
+			}
+			if !closed {
+				return nil, fmt.Errorf("unterminated double quote")
+			}
+			inWord = true
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
@@ -0,0 +1,319 @@
+package goparse
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// parserSpec is the declarative form of a parser read by NewParserFromSpec
+type parserSpec struct {
+	Name			string				`json:"name"`
+	Description		string				`json:"description"`
+	Author			string				`json:"author"`
+	Version			string				`json:"version"`
+	Arguments		[]argumentSpec		`json:"arguments"`
+	ExclusiveGroups	[]exclusiveSpec		`json:"exclusive_groups"`
+}
+
+type argumentSpec struct {
+	Name			string			`json:"name"`
+	Short			string			`json:"short"`
+	Long			string			`json:"long"`
+	Type			string			`json:"type"`
+	Description		string			`json:"description"`
+	Required		bool			`json:"required"`
+	Default			interface{}		`json:"default"`
+	Choices			[]interface{}	`json:"choices"`
+}
+
+type exclusiveSpec struct {
+	Options		[]string	`json:"options"`
+	Required	bool		`json:"required"`
+	Default		string		`json:"default"`
+	Title		string		`json:"title"`
+}
+
+// NewParserFromSpec builds a parser from a JSON or YAML description of the
+// program metadata, its arguments and its exclusive groups, e.g.
+//
+//	name: mytool
+//	arguments:
+//	  - name: format
+//	    short: f
+//	    long: format
+//	    type: string
+//	    default: text
+//	    choices: [text, json]
+//	exclusive_groups:
+//	  - options: [quiet, verbose]
+//
+// Input starting with "{" is read as JSON, anything else as YAML.
+func NewParserFromSpec(r io.Reader) (*Parser, error) {
+	contents, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("could not read spec: %v", err)
+	}
+
+	// YAML is decoded into generic values and re-encoded, so both formats
+	// share the strict JSON decoding below
+	if !bytes.HasPrefix(bytes.TrimSpace(contents), []byte("{")) {
+		document, err := parseYAMLDocument(contents)
+		if err != nil {
+			return nil, fmt.Errorf("invalid spec: %v", err)
+		}
+		if contents, err = json.Marshal(document); err != nil {
+			return nil, fmt.Errorf("invalid spec: %v", err)
+		}
+	}
+
+	var spec parserSpec
+	decoder := json.NewDecoder(bytes.NewReader(contents))
+	decoder.DisallowUnknownFields()
+	decoder.UseNumber()
+	if err := decoder.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("invalid spec: %v", err)
+	}
+
+	p := NewParser(
+		WithName(spec.Name),
+		WithDescription(spec.Description),
+		WithAuthor(spec.Author),
+		WithVersion(spec.Version),
+	)
+
+	for i, argSpec := range spec.Arguments {
+		if argSpec.Name == "" {
+			return nil, fmt.Errorf("invalid spec: argument %d has no name", i+1)
+		}
+		if !knownDataTypes[argSpec.Type] {
+			return nil, fmt.Errorf("invalid spec: argument '%s' has unknown type '%s'", argSpec.Name, argSpec.Type)
+		}
+		if p.lookupArgument(argSpec.Name) != nil {
+			return nil, fmt.Errorf("invalid spec: argument '%s' is defined more than once", argSpec.Name)
+		}
+
+		arg := p.AddArgument(argSpec.Name, argSpec.Short, argSpec.Long, argSpec.Description, argSpec.Type, argSpec.Required)
+		if argSpec.Default != nil {
+			value, err := convertConfigValue(arg, argSpec.Default)
+			if err != nil {
+				return nil, fmt.Errorf("invalid spec: bad default: %v", err)
+			}
+			arg.DefaultValue = value
+		}
+		for _, choice := range argSpec.Choices {
+			arg.choices = append(arg.choices, fmt.Sprint(choice))
+		}
+	}
+
+	for i, group := range spec.ExclusiveGroups {
+		if len(group.Options) < 2 {
+			return nil, fmt.Errorf("invalid spec: exclusive group %d needs at least two options", i+1)
+		}
+		for _, name := range group.Options {
+			if p.lookupArgument(name) == nil {
+				return nil, fmt.Errorf("invalid spec: exclusive group %d references unknown argument '%s'", i+1, name)
+			}
+		}
+		exclusive := p.AddExclusiveGroup(group.Options, group.Required)
+		exclusive.Default, exclusive.Title = group.Default, group.Title
+	}
+
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid spec: %v", err)
+	}
+	return p, nil
+}
+
+// yamlLine is a significant line of a YAML document
+type yamlLine struct {
+	number	int
+	indent	int
+	text	string
+}
+
+// parseYAMLDocument handles the block subset of YAML a spec needs: nested
+// mappings, "- item" sequences (including sequences of mappings), [a, b] flow
+// lists, scalars and # comments.
+func parseYAMLDocument(contents []byte) (interface{}, error) {
+	lines := []yamlLine{}
+	for i, raw := range strings.Split(string(contents), "\n") {
+		text := strings.TrimRight(raw, " \t\r")
+		trimmed := strings.TrimLeft(text, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		lines = append(lines, yamlLine{number: i + 1, indent: len(text) - len(trimmed), text: trimmed})
+	}
+	if len(lines) == 0 {
+		return map[string]interface{}{}, nil
+	}
+
+	value, next, err := parseYAMLNode(lines, 0, lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if next < len(lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", lines[next].number)
+	}
+	return value, nil
+}
+
+// parseYAMLNode parses the mapping or sequence starting at lines[start] whose
+// entries sit at indent, returning it and the index of the first line after it.
+func parseYAMLNode(lines []yamlLine, start, indent int) (interface{}, int, error) {
+	if strings.HasPrefix(lines[start].text, "- ") || lines[start].text == "-" {
+		return parseYAMLSequence(lines, start, indent)
+	}
+	return parseYAMLMapping(lines, start, indent)
+}
+
+// parseYAMLSequence parses "- item" lines at indent. A sequence at the same
+// indent as its key ends at the next line that is not an item, which is left
+// to the enclosing mapping.
+func parseYAMLSequence(lines []yamlLine, start, indent int) (interface{}, int, error) {
+	items := []interface{}{}
+	i := start
+	for i < len(lines) && lines[i].indent == indent {
+		line := lines[i]
+		if !strings.HasPrefix(line.text, "- ") && line.text != "-" {
+			break
+		}
+		item := strings.TrimSpace(strings.TrimPrefix(line.text, "-"))
+
+		switch {
+		case item == "":
+			// The item is the nested block on the following lines
+			if i+1 >= len(lines) || lines[i+1].indent <= indent {
+				items = append(items, nil)
+				i++
+				continue
+			}
+			value, next, err := parseYAMLNode(lines, i+1, lines[i+1].indent)
+			if err != nil {
+				return nil, next, err
+			}
+			items = append(items, value)
+			i = next
+		case isYAMLMappingEntry(item):
+			// "- key: value" starts a mapping whose keys line up after the dash
+			itemIndent := indent + len(line.text) - len(item)
+			lines[i] = yamlLine{number: line.number, indent: itemIndent, text: item}
+			value, next, err := parseYAMLMapping(lines, i, itemIndent)
+			if err != nil {
+				return nil, next, err
+			}
+			items = append(items, value)
+			i = next
+		default:
+			items = append(items, parseYAMLScalar(item))
+			i++
+		}
+	}
+	return items, i, nil
+}
+
+func parseYAMLMapping(lines []yamlLine, start, indent int) (interface{}, int, error) {
+	values := map[string]interface{}{}
+	i := start
+	for i < len(lines) && lines[i].indent == indent {
+		line := lines[i]
+		if !isYAMLMappingEntry(line.text) {
+			return nil, i, fmt.Errorf("line %d: expected 'key: value'", line.number)
+		}
+		key, value, _ := strings.Cut(line.text, ":")
+		key, value = unquoteYAML(key), strings.TrimSpace(value)
+		i++
+
+		if value != "" {
+			values[key] = parseYAMLScalar(value)
+			continue
+		}
+
+		// An empty value introduces a nested block, or is null without one.
+		// Sequences may sit at the same indent as their key.
+		switch {
+		case i < len(lines) && lines[i].indent > indent:
+			nested, next, err := parseYAMLNode(lines, i, lines[i].indent)
+			if err != nil {
+				return nil, next, err
+			}
+			values[key] = nested
+			i = next
+		case i < len(lines) && lines[i].indent == indent && (strings.HasPrefix(lines[i].text, "- ") || lines[i].text == "-"):
+			nested, next, err := parseYAMLSequence(lines, i, indent)
+			if err != nil {
+				return nil, next, err
+			}
+			values[key] = nested
+			i = next
+		default:
+			values[key] = nil
+		}
+	}
+	if i < len(lines) && lines[i].indent > indent {
+		return nil, i, fmt.Errorf("line %d: unexpected indentation", lines[i].number)
+	}
+	return values, i, nil
+}
+
+// isYAMLMappingEntry reports whether text is a "key: value" or "key:" entry
+func isYAMLMappingEntry(text string) bool {
+	if text == "" || text[0] == '"' || text[0] == '\'' || text[0] == '[' {
+		return false
+	}
+	key, value, found := strings.Cut(text, ":")
+	return found && key != "" && (value == "" || value[0] == ' ')
+}
+
+// parseYAMLScalar converts a scalar or [a, b] flow list to a Go value. Quoted
+// values are always strings.
+func parseYAMLScalar(value string) interface{} {
+	if value[0] != '"' && value[0] != '\'' {
+		if before, _, found := strings.Cut(value, " #"); found {
+			value = strings.TrimSpace(before)
+		}
+	} else if end := strings.IndexByte(value[1:], value[0]) + 1; end > 0 {
+		// A comment may follow the closing quote
+		if rest := strings.TrimSpace(value[end+1:]); strings.HasPrefix(rest, "#") {
+			value = value[:end+1]
+		}
+	}
+
+	if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
+		items := []interface{}{}
+		for _, item := range strings.Split(value[1:len(value)-1], ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, parseYAMLScalar(item))
+			}
+		}
+		return items
+	}
+
+	if value[0] == '"' || value[0] == '\'' {
+		return unquoteYAML(value)
+	}
+	switch value {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null", "~":
+		return nil
+	}
+	if intValue, err := strconv.Atoi(value); err == nil {
+		return intValue
+	}
+	if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+		// Keep the text of numbers JSON can carry, so converting them later
+		// neither rounds large integers nor switches to exponent form
+		if json.Valid([]byte(value)) {
+			return json.Number(value)
+		}
+		return floatValue
+	}
+	return value
+}
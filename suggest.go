@@ -0,0 +1,45 @@
+package goparse
+
+// suggest returns the candidate closest to input by edit distance, or "" if
+// none is close enough to be a plausible typo.
+func suggest(input string, candidates []string) string {
+	best, bestDistance := "", 0
+	for _, candidate := range candidates {
+		distance := editDistance(input, candidate)
+		if best == "" || distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+
+	// Allow roughly one typo per three characters, and at least one
+	limit := len(input) / 3
+	if limit < 1 {
+		limit = 1
+	}
+	if best == "" || bestDistance > limit {
+		return ""
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
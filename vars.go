@@ -0,0 +1,88 @@
+package goparse
+
+// StringVar adds a string argument whose parsed value is stored in *target,
+// in the style of the standard flag package.
+func (p *Parser) StringVar(target *string, name, short, long, defaultValue, description string) *Argument {
+	arg := p.AddArgument(name, short, long, description, "string", false, defaultValue)
+	p.bind(arg, func(value interface{}) {
+		if s, ok := value.(string); ok {
+			*target = s
+		}
+	})
+	*target = defaultValue
+	return arg
+}
+
+// IntVar adds an int argument whose parsed value is stored in *target
+func (p *Parser) IntVar(target *int, name, short, long string, defaultValue int, description string) *Argument {
+	arg := p.AddArgument(name, short, long, description, "int", false, defaultValue)
+	p.bind(arg, func(value interface{}) {
+		if n, ok := value.(int); ok {
+			*target = n
+		}
+	})
+	*target = defaultValue
+	return arg
+}
+
+// BoolVar adds a bool argument whose parsed value is stored in *target
+func (p *Parser) BoolVar(target *bool, name, short, long string, defaultValue bool, description string) *Argument {
+	arg := p.AddArgument(name, short, long, description, "bool", false, defaultValue)
+	p.bind(arg, func(value interface{}) {
+		if b, ok := value.(bool); ok {
+			*target = b
+		}
+	})
+	*target = defaultValue
+	return arg
+}
+
+// Float64Var adds a float64 argument whose parsed value is stored in *target
+func (p *Parser) Float64Var(target *float64, name, short, long string, defaultValue float64, description string) *Argument {
+	arg := p.AddArgument(name, short, long, description, "float64", false, defaultValue)
+	p.bind(arg, func(value interface{}) {
+		if f, ok := value.(float64); ok {
+			*target = f
+		}
+	})
+	*target = defaultValue
+	return arg
+}
+
+// StringSliceVar adds a []string argument whose parsed values are stored in *target
+func (p *Parser) StringSliceVar(target *[]string, name, short, long string, defaultValue []string, description string) *Argument {
+	var def interface{}
+	if defaultValue != nil {
+		def = defaultValue
+	}
+	arg := p.AddArgument(name, short, long, description, "[]string", false, def)
+	p.bind(arg, func(value interface{}) {
+		if values, ok := value.([]string); ok {
+			*target = values
+		}
+	})
+	*target = defaultValue
+	return arg
+}
+
+// bind registers a setter that receives the argument's final value after a
+// successful parse.
+func (p *Parser) bind(arg *Argument, set func(value interface{})) {
+	p.bindings = append(p.bindings, binding{arg: arg, set: set})
+}
+
+// applyBindings copies parsed values into the variables registered with the
+// *Var methods.
+func (p *Parser) applyBindings(parsedArgs map[string]interface{}) {
+	for _, b := range p.bindings {
+		if value, ok := parsedArgs[b.arg.key()]; ok {
+			b.set(value)
+		}
+	}
+}
+
+// binding ties an argument to the variable its value is stored in
+type binding struct {
+	arg	*Argument
+	set	func(value interface{})
+}